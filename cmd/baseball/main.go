@@ -17,56 +17,161 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/bauer312/baseball/pkg/command"
+	"github.com/bauer312/baseball/pkg/config"
+	"github.com/bauer312/baseball/pkg/summary"
 )
 
 func main() {
-	if len(os.Args) == 1 {
-		printCommands()
-	} else {
-		cmdMap := make(map[string]*string)
-		fs := flag.NewFlagSet("cmd", flag.ContinueOnError)
-		var cmdStruct command.Command
-
-		cmd := strings.ToLower(os.Args[1])
-		switch cmd {
-		case "savant":
-			cmdStruct = &command.GetSavantGames{}
-		case "gameday":
-			cmdStruct = &command.GetGamedayGames{}
-		case "weather":
-			cmdStruct = &command.ExtractWeatherLink{}
-		case "loadsavant":
-			cmdStruct = &command.LoadSavantData{}
-		case "loadgameday":
-			cmdStruct = &command.LoadGamedayData{}
-		default:
-			printCommands()
-			return
-		}
+	os.Exit(run(os.Args[1:]))
+}
+
+/*
+run runs the command named by the first argument and returns the exit code
+	(see command.ExitCode)
+*/
+func run(arguments []string) int {
+	if len(arguments) == 0 {
+		command.PrintCommands(os.Stdout)
+		return command.ExitSuccess
+	}
+	name := strings.ToLower(arguments[0])
+	reg, ok := command.Lookup(name)
+	if ok == false {
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n\n", arguments[0])
+		command.PrintCommands(os.Stderr)
+		return command.ExitUsage
+	}
+
+	var globals command.Globals
+	fs, cmd := command.NewFlagSet(reg, &globals)
+	args, err := command.ParseArgs(fs, arguments[1:])
+	if err == flag.ErrHelp {
+		return command.ExitSuccess
+	}
+	if err != nil {
+		// The flag package has already explained the problem
+		return command.ExitUsage
+	}
+	if len(args) > 0 && len(reg.Args) == 0 {
+		fmt.Fprintf(os.Stderr, "%s doesn't take any arguments: %s\n", name, strings.Join(args, " "))
+		return command.ExitUsage
+	}
+
+	logger, err := newLogger(globals.LogFormat, globals.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return command.ExitUsage
+	}
+	slog.SetDefault(logger)
 
-		cmdStruct.SetFlags(fs, cmdMap)
+	cfg, err := config.Load(globals.Config)
+	if err != nil {
+		slog.Error("Unable to load the config", "err", err)
+		return command.ExitUsage
+	}
+	applyConfig(fs, cfg, name)
 
-		fs.Parse(os.Args[2:])
+	fs.VisitAll(func(f *flag.Flag) {
+		value := config.Setting{Name: config.DatabaseDSN, Value: f.Value.String()}.Redacted()
+		slog.Debug("Flag", "name", f.Name, "value", value)
+	})
 
-		for k, v := range cmdMap {
-			fmt.Println(k, *v)
+	// The first Ctrl-C cancels the context so that the command can stop
+	//	cleanly; after that, the default handling is restored so that a
+	//	second Ctrl-C ends the process right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+			if reg.UntilStopped == false {
+				slog.Warn("Stopping; press Ctrl-C again to quit immediately")
+			}
+		case <-finished:
 		}
+	}()
+
+	results := summary.New(name)
+	err = cmd.Execute(config.NewContext(summary.NewContext(ctx, results), cfg), args)
+	close(finished)
+
+	interrupted := ctx.Err() != nil && reg.UntilStopped == false
+	stop()
 
-		cmdStruct.Execute(cmdMap)
+	var usageErr *command.UsageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(os.Stderr, "%s\nRun 'baseball help %s' for usage.\n", err, name)
+		return command.ExitUsage
+	}
+	if err != nil {
+		slog.Error("The command failed", "command", name, "err", err)
+		results.Fail(name, err)
+	}
+	if reg.NoSummary == false {
+		results.Print(os.Stdout, interrupted)
+	}
+
+	switch {
+	case interrupted:
+		return command.ExitInterrupted
+	case err != nil:
+		return command.ExitCode(err)
+	case results.Failed() > 0:
+		return command.ExitFailure
+	}
+	return command.ExitSuccess
+}
+
+/*
+applyConfig fills in every flag that wasn't given on the command line with
+	the value from the config, which has already taken the environment and
+	the config file into account
+*/
+func applyConfig(fs *flag.FlagSet, cfg *config.Config, cmd string) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for name, value := range cfg.Flags(cmd) {
+		if given[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		err := fs.Set(name, value)
+		if err != nil {
+			slog.Warn("Unable to apply the config", "flag", name, "value", value, "err", err)
+		}
 	}
 }
 
-func printCommands() {
-	fmt.Println("Available Commands")
-	fmt.Println("\tsavant")
-	fmt.Println("\tgameday")
-	fmt.Println("\tweather")
-	fmt.Println("\tloadsavant")
-	fmt.Println("\tloadgameday")
+/*
+newLogger builds the logger that every package writes to through the default
+	slog logger
+*/
+func newLogger(format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	err := lvl.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %s", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %s", format)
 }
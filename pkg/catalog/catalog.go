@@ -0,0 +1,217 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+/*
+Package catalog describes the sports that gameday covers and the leagues,
+	divisions and time zones their scoreboards refer to.  The catalog is kept
+	in catalog.toml, which is built into the program, so that supporting a new
+	league is a matter of adding a few lines of data.
+*/
+package catalog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	// The time zones have to be found on systems without a time zone database
+	_ "time/tzdata"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed catalog.toml
+var catalogData string
+
+/*
+DefaultSport is the sport code of the major leagues
+*/
+const DefaultSport = "mlb"
+
+/*
+DefaultTimeZone is the time zone gameday uses for most of its times
+*/
+const DefaultTimeZone = "ET"
+
+/*
+ErrUnknown is returned for a code that isn't in the catalog
+*/
+var ErrUnknown = errors.New("not in the catalog")
+
+/*
+Sport is a level of play that has its own gameday tree, such as mlb or aaa
+*/
+type Sport struct {
+	Code string `toml:"code"`
+	Name string `toml:"name"`
+}
+
+/*
+League is a league as identified in the scoreboard, along with its sport
+*/
+type League struct {
+	ID    int64  `toml:"id"`
+	Name  string `toml:"name"`
+	Sport string `toml:"sport"`
+}
+
+/*
+Division is the code of a division in the scoreboard and its name
+*/
+type Division struct {
+	Code string `toml:"code"`
+	Name string `toml:"name"`
+}
+
+/*
+TimeZone is the code of a time zone in the scoreboard and the IANA location
+	it stands for
+*/
+type TimeZone struct {
+	Code     string `toml:"code"`
+	Location string `toml:"location"`
+}
+
+type catalog struct {
+	Sports    []Sport    `toml:"sport"`
+	Leagues   []League   `toml:"league"`
+	Divisions []Division `toml:"division"`
+	TimeZones []TimeZone `toml:"timezone"`
+}
+
+var (
+	loadOnce  sync.Once
+	loaded    catalog
+	locations sync.Map
+)
+
+/*
+get returns the catalog, reading it the first time it is needed
+*/
+func get() *catalog {
+	loadOnce.Do(func() {
+		if _, err := toml.Decode(catalogData, &loaded); err != nil {
+			panic(fmt.Sprintf("the built in catalog is invalid: %s", err))
+		}
+	})
+	return &loaded
+}
+
+/*
+Sports returns every sport in the catalog, sorted by code
+*/
+func Sports() []Sport {
+	sports := append([]Sport(nil), get().Sports...)
+	sort.Slice(sports, func(i, j int) bool {
+		return sports[i].Code < sports[j].Code
+	})
+	return sports
+}
+
+/*
+SportCodes returns the codes of every sport, separated by commas, for use in
+	flag descriptions and error messages
+*/
+func SportCodes() string {
+	var codes []string
+	for _, sport := range Sports() {
+		codes = append(codes, sport.Code)
+	}
+	return strings.Join(codes, ", ")
+}
+
+/*
+SportHelp describes the values accepted by a sport flag so that every command
+	can use the same flag description
+*/
+func SportHelp() string {
+	return "The `code` of the sport whose gameday tree is read: " + SportCodes()
+}
+
+/*
+LookupSport finds a sport by its code, ignoring case
+*/
+func LookupSport(code string) (Sport, bool) {
+	for _, sport := range get().Sports {
+		if strings.EqualFold(sport.Code, code) {
+			return sport, true
+		}
+	}
+	return Sport{}, false
+}
+
+/*
+LookupLeague finds a league by its ID
+*/
+func LookupLeague(id int64) (League, bool) {
+	for _, league := range get().Leagues {
+		if league.ID == id {
+			return league, true
+		}
+	}
+	return League{}, false
+}
+
+/*
+LookupDivision finds a division by its code
+*/
+func LookupDivision(code string) (Division, bool) {
+	for _, division := range get().Divisions {
+		if division.Code == code {
+			return division, true
+		}
+	}
+	return Division{}, false
+}
+
+/*
+Location returns the location of a time zone code.  A code that isn't in the
+	catalog returns an error wrapping ErrUnknown; any other error means the
+	time zone database of the system doesn't know the location.
+*/
+func Location(code string) (*time.Location, error) {
+	if location, ok := locations.Load(code); ok {
+		return location.(*time.Location), nil
+	}
+	for _, zone := range get().TimeZones {
+		if zone.Code != code {
+			continue
+		}
+		location, err := time.LoadLocation(zone.Location)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the location of time zone %s: %w", code, err)
+		}
+		locations.Store(code, location)
+		return location, nil
+	}
+	return nil, fmt.Errorf("time zone %q: %w", code, ErrUnknown)
+}
+
+/*
+DayPath returns the gameday directory of a sport on a date, without a trailing
+	slash, for example
+		http://gd2.mlb.com/components/game/aaa/year_2019/month_06/day_10
+*/
+func DayPath(baseURL, sport string, date time.Time) string {
+	if len(sport) == 0 {
+		sport = DefaultSport
+	}
+	return fmt.Sprintf("%s/components/game/%s/year_%04d/month_%02d/day_%02d",
+		baseURL, strings.ToLower(sport), date.Year(), date.Month(), date.Day())
+}
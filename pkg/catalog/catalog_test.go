@@ -0,0 +1,72 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package catalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCatalog(t *testing.T) {
+	for _, code := range []string{"mlb", "aaa", "aax", "afa", "win", "int", "AAA"} {
+		if _, ok := LookupSport(code); ok == false {
+			t.Errorf("Expected sport %s to be in the catalog", code)
+		}
+	}
+	if _, ok := LookupSport("xyz"); ok {
+		t.Errorf("Did not expect sport xyz to be in the catalog")
+	}
+
+	var leagueTests = []struct {
+		ID           int64
+		ExpectedName string
+		ExpectedOK   bool
+	}{
+		{103, "American League", true},
+		{104, "National League", true},
+		{117, "International League", true},
+		{115, "Grapefruit League", true},
+		{999, "", false},
+	}
+	for _, ex := range leagueTests {
+		league, ok := LookupLeague(ex.ID)
+		if ok != ex.ExpectedOK || league.Name != ex.ExpectedName {
+			t.Errorf("League %d -> %q %v, expected %q %v", ex.ID, league.Name, ok, ex.ExpectedName, ex.ExpectedOK)
+		}
+	}
+
+	if division, ok := LookupDivision("C"); ok == false || division.Name != "Central" {
+		t.Errorf("Expected division C to be Central, got %q", division.Name)
+	}
+
+	location, err := Location("PT")
+	if err != nil || location.String() != "America/Los_Angeles" {
+		t.Errorf("Expected PT to be America/Los_Angeles, got %v %v", location, err)
+	}
+	if _, err := Location("XT"); errors.Is(err, ErrUnknown) == false {
+		t.Errorf("Expected an unknown time zone, got %v", err)
+	}
+
+	date := time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)
+	if path := DayPath("http://gd2.mlb.com", "aaa", date); path != "http://gd2.mlb.com/components/game/aaa/year_2019/month_06/day_10" {
+		t.Errorf("Unexpected day path %s", path)
+	}
+	if path := DayPath("", "", date); path != "/components/game/mlb/year_2019/month_06/day_10" {
+		t.Errorf("Unexpected default day path %s", path)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/db"
+	"github.com/bauer312/baseball/pkg/pipelinestage"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+savantLoadColumns is the number of columns LoadSavantCSV reads from each row
+*/
+const savantLoadColumns = 88
+
+/*
+savantKeyColumns are the columns every Savant CSV needs, in the order that
+	identifies a pitch after the date
+*/
+var savantKeyColumns = []string{"game_date", "game_pk", "at_bat_number", "pitch_number"}
+
+/*
+checkSavantFile makes sure that a Savant CSV can be loaded: it has to be a CSV
+	with the columns the loader reads, every row has to have as many columns
+	as the header, every pitch has to be from the date in the file name and
+	no pitch may appear twice.  An empty file is a day without any pitches.
+	The file may be compressed.
+*/
+func checkSavantFile(path string) (int, error) {
+	data, err := util.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if err = checkNotPage(data); err != nil {
+		return 0, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return 0, errors.New("contains a web page instead of CSV")
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	header, err := r.Read()
+	if err != nil {
+		return 0, err
+	}
+	if len(header) < savantLoadColumns {
+		return 0, fmt.Errorf("the header has %d columns but loading needs %d", len(header), savantLoadColumns)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimPrefix(name, "\ufeff")] = i
+	}
+	keys := make([]int, len(savantKeyColumns))
+	for i, name := range savantKeyColumns {
+		column, ok := columns[name]
+		if ok == false {
+			return 0, fmt.Errorf("the header is missing %s", name)
+		}
+		keys[i] = column
+	}
+
+	fileDate := ""
+	if dt, err := time.Parse("20060102", strings.TrimSuffix(util.TrimCompression(filepath.Base(path)), ".csv")); err == nil {
+		fileDate = dt.Format("2006-01-02")
+	}
+	seen := make(map[string]bool)
+	rows, repeated := 0, 0
+	for {
+		// The reader checks that each row has as many columns as the header
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows++
+		if len(fileDate) > 0 && row[keys[0]] != fileDate {
+			line, _ := r.FieldPos(0)
+			return rows, fmt.Errorf("line %d is a pitch from %s", line, row[keys[0]])
+		}
+		key := row[keys[1]] + "|" + row[keys[2]] + "|" + row[keys[3]]
+		if seen[key] {
+			repeated++
+		}
+		seen[key] = true
+	}
+	if repeated > 0 {
+		return rows, fmt.Errorf("%d pitches appear more than once", repeated)
+	}
+	return rows, nil
+}
+
+/*
+checkGamedayFile makes sure that a Gameday file is a well formed XML document.
+	The files that are loaded, inning_all and master_scoreboard, also have to
+	decode into the structures that load them.  The file may be compressed.
+*/
+func checkGamedayFile(path string) error {
+	data, err := util.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return errors.New("is empty")
+	}
+	if err = checkNotPage(data); err != nil {
+		return err
+	}
+
+	root, err := xmlRoot(data)
+	if err != nil {
+		return fmt.Errorf("is not well formed XML: %w", err)
+	}
+	if strings.EqualFold(root, "html") {
+		return errors.New("contains a web page instead of XML")
+	}
+
+	name := util.TrimCompression(filepath.Base(path))
+	var expected string
+	var structure interface{}
+	switch {
+	case strings.HasSuffix(name, "_inning_all.xml"):
+		expected, structure = "game", &db.GameXML{}
+	case strings.HasSuffix(name, "master_scoreboard.xml"):
+		expected, structure = "games", &pipelinestage.ScoreboardXMLGames{}
+	default:
+		return nil
+	}
+	if root != expected {
+		return fmt.Errorf("has a %s element where a %s element was expected", root, expected)
+	}
+	if err = xml.Unmarshal(data, structure); err != nil {
+		return fmt.Errorf("does not match the %s structure: %w", expected, err)
+	}
+	return nil
+}
+
+/*
+checkNotPage catches the files that were saved along with the HTTP status line
+	and headers of the response
+*/
+func checkNotPage(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimLeft(data, "\ufeff \t\r\n"), []byte("HTTP/")) {
+		return errors.New("starts with the HTTP response headers")
+	}
+	return nil
+}
+
+/*
+xmlRoot reads the whole document, so that any error in it is found, and
+	returns the name of the root element
+*/
+func xmlRoot(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := ""
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if len(root) > 0 {
+					return "", fmt.Errorf("more than one root element")
+				}
+				root = t.Name.Local
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return "", fmt.Errorf("text outside of the root element")
+			}
+		}
+	}
+	if len(root) == 0 {
+		return "", fmt.Errorf("no root element")
+	}
+	return root, nil
+}
@@ -0,0 +1,85 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckArchiveFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// A Savant row with the date, game, at bat and pitch in the first columns
+	columns := make([]string, savantLoadColumns)
+	copy(columns, savantKeyColumns)
+	for i := len(savantKeyColumns); i < savantLoadColumns; i++ {
+		columns[i] = fmt.Sprintf("column_%d", i)
+	}
+	header := "\ufeff" + strings.Join(columns, ",") + "\n"
+	row := func(date string, pitch int) string {
+		values := make([]string, savantLoadColumns)
+		values[0], values[1], values[2], values[3] = date, "565000", "1", fmt.Sprint(pitch)
+		return strings.Join(values, ",") + "\n"
+	}
+	inningAll := `<game atBat="1" deck="2" hole="3" ind="F"><inning num="1" away_team="nya" home_team="bos" next="Y"><top></top><bottom></bottom></inning></game>`
+
+	var checkTest = []struct {
+		Name     string
+		Content  string
+		Expected string
+	}{
+		{"20190610.csv", header + row("2019-06-10", 1) + row("2019-06-10", 2), ""},
+		{"20190611.csv", "", ""},
+		{"20190612.csv", "<html><body>Too many requests</body></html>", "web page"},
+		{"20190613.csv", "pitch_type,game_date\nFF,2019-06-13\n", "loading needs 88"},
+		{"20190614.csv", header + row("2019-06-14", 1) + "FF,2019-06-14\n", "wrong number of fields"},
+		{"20190615.csv", header + row("2019-06-15", 1) + row("2019-06-16", 2), "is a pitch from 2019-06-16"},
+		{"20190617.csv", header + row("2019-06-17", 1) + row("2019-06-17", 1), "1 pitches appear more than once"},
+		{"gid_2019_06_10_nyamlb_bosmlb_1_inning_inning_all.xml", inningAll, ""},
+		{"gid_2019_06_11_nyamlb_bosmlb_1_inning_inning_all.xml", "HTTP/1.1 200 OK\r\nContent-Type: text/xml\r\n\r\n" + inningAll, "HTTP response headers"},
+		{"gid_2019_06_12_nyamlb_bosmlb_1_inning_inning_all.xml", "", "is empty"},
+		{"gid_2019_06_13_nyamlb_bosmlb_1_inning_inning_all.xml", `<game><inning num="1">`, "not well formed"},
+		{"gid_2019_06_14_nyamlb_bosmlb_1_inning_inning_all.xml", `<boxscore game_id="1"/>`, "where a game element was expected"},
+		{"gid_2019_06_15_nyamlb_bosmlb_1_inning_inning_all.xml", `<game><inning num="first"></inning></game>`, "does not match the game structure"},
+		{"gid_2019_06_16_nyamlb_bosmlb_1_game.xml", `<!DOCTYPE html><html><body></body></html>`, "web page"},
+		{"gid_2019_06_17_nyamlb_bosmlb_1_boxscore.xml", `<boxscore game_id="1"/>`, ""},
+		{"master_scoreboard.xml", `<games year="2019" month="6" day="10"><game id="2019/06/10/nyamlb-bosmlb-1"/></games>`, ""},
+	}
+
+	for _, tt := range checkTest {
+		path := filepath.Join(dir, tt.Name)
+		if err := os.WriteFile(path, []byte(tt.Content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if strings.HasSuffix(tt.Name, ".csv") {
+			_, err = checkSavantFile(path)
+		} else {
+			err = checkGamedayFile(path)
+		}
+		if len(tt.Expected) == 0 && err != nil {
+			t.Errorf("%s: unexpected problem %s", tt.Name, err)
+		}
+		if len(tt.Expected) > 0 && (err == nil || strings.Contains(err.Error(), tt.Expected) == false) {
+			t.Errorf("%s: expected a problem with %q, got %v", tt.Name, tt.Expected, err)
+		}
+	}
+}
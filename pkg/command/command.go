@@ -16,12 +16,264 @@
 
 package command
 
-import "flag"
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/catalog"
+	"github.com/bauer312/baseball/pkg/dateslice"
+	"github.com/bauer312/baseball/pkg/util"
+)
 
 /*
-Command is the interface that all commands must conform to
+Command is the interface that all commands must conform to.  SetFlags binds the
+	flags of the command to its fields, and Execute runs it with whatever
+	arguments follow the flags.  Execute should stop as soon as it can when
+	the context is cancelled, and should report the work it does to the
+	summary carried by the context (see summary.FromContext).  The error it
+	returns is for a failure of the whole command; see ExitCode.
 */
 type Command interface {
-	SetFlags(*flag.FlagSet, map[string]*string)
-	Execute(map[string]*string)
+	SetFlags(*flag.FlagSet)
+	Execute(context.Context, []string) error
+}
+
+/*
+Exit codes of the baseball program
+*/
+const (
+	// ExitSuccess is returned when everything worked
+	ExitSuccess = 0
+	// ExitFailure is returned when the command, or any of its work, failed
+	ExitFailure = 1
+	// ExitUsage is returned for a bad command line or config
+	ExitUsage = 2
+	// ExitInterrupted is returned after Ctrl-C or SIGTERM, as a shell would
+	ExitInterrupted = 130
+)
+
+/*
+UsageError is returned by a command that was given flags or arguments it
+	can't use
+*/
+type UsageError struct {
+	Err error
+}
+
+func (ue *UsageError) Error() string {
+	return ue.Err.Error()
+}
+
+func (ue *UsageError) Unwrap() error {
+	return ue.Err
+}
+
+/*
+usageErrorf creates a UsageError in the manner of fmt.Errorf
+*/
+func usageErrorf(format string, a ...interface{}) error {
+	return &UsageError{Err: fmt.Errorf(format, a...)}
+}
+
+/*
+ExitCode is the exit code for the error returned by Execute
+*/
+func ExitCode(err error) int {
+	var ue *UsageError
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.As(err, &ue):
+		return ExitUsage
+	}
+	return ExitFailure
+}
+
+/*
+dateValue is a flag holding a date: one of the names dateslice understands
+	(today, yesterday, thisweek, lastweek, thismonth or lastmonth) or a year,
+	month or day written as YYYY, YYYYMM or YYYYMMDD.  Anything else is
+	rejected when the flags are parsed.
+*/
+type dateValue string
+
+func (d *dateValue) String() string {
+	if d == nil {
+		return ""
+	}
+	return string(*d)
+}
+
+func (d *dateValue) Set(s string) error {
+	if len(s) > 0 && len(dateslice.DateStringToSlice(s)) == 0 {
+		layouts := map[int]string{4: "2006", 6: "200601", 8: "20060102"}
+		layout, ok := layouts[len(s)]
+		if ok == false {
+			return fmt.Errorf("%s is not a date name, YYYY, YYYYMM or YYYYMMDD", s)
+		}
+		if _, err := time.Parse(layout, s); err != nil {
+			return fmt.Errorf("%s is not a date name, YYYY, YYYYMM or YYYYMMDD", s)
+		}
+	}
+	*d = dateValue(s)
+	return nil
+}
+
+/*
+dateFlag defines a date flag (see dateValue)
+*/
+func dateFlag(fs *flag.FlagSet, d *dateValue, name, value, usage string) {
+	*d = dateValue(value)
+	fs.Var(d, name, usage)
+}
+
+/*
+pathValue is a flag holding a file or directory.  A leading ~ is expanded to
+	the home directory, since the shell doesn't do that for -flag=~/path or
+	for paths in the config file.
+*/
+type pathValue string
+
+func (p *pathValue) String() string {
+	if p == nil {
+		return ""
+	}
+	return string(*p)
+}
+
+func (p *pathValue) Set(s string) error {
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		s = filepath.Join(home, s[1:])
+	}
+	if len(s) > 0 {
+		s = filepath.Clean(s)
+	}
+	*p = pathValue(s)
+	return nil
+}
+
+/*
+pathFlag defines a path flag (see pathValue)
+*/
+func pathFlag(fs *flag.FlagSet, p *pathValue, name, value, usage string) {
+	*p = pathValue(value)
+	fs.Var(p, name, usage)
+}
+
+/*
+compressValue is a flag holding the format downloaded files are stored in:
+	none, gz or zst (see util.ParseCompression)
+*/
+type compressValue string
+
+func (c *compressValue) String() string {
+	if c == nil {
+		return ""
+	}
+	return string(*c)
+}
+
+func (c *compressValue) Set(s string) error {
+	format, err := util.ParseCompression(s)
+	if err != nil {
+		return err
+	}
+	*c = compressValue(format)
+	return nil
+}
+
+/*
+compressFlag defines a compression flag (see compressValue)
+*/
+func compressFlag(fs *flag.FlagSet, c *compressValue, name, value, usage string) {
+	*c = compressValue(value)
+	fs.Var(c, name, usage)
+}
+
+/*
+sportValue is a flag holding the code of a sport in the catalog, such as mlb
+	or aaa (see catalog.LookupSport)
+*/
+type sportValue string
+
+func (s *sportValue) String() string {
+	if s == nil {
+		return ""
+	}
+	return string(*s)
+}
+
+func (s *sportValue) Set(value string) error {
+	sport, ok := catalog.LookupSport(value)
+	if ok == false {
+		return fmt.Errorf("unknown sport %s (expected one of %s)", value, catalog.SportCodes())
+	}
+	*s = sportValue(sport.Code)
+	return nil
+}
+
+/*
+sportFlag defines a sport flag (see sportValue)
+*/
+func sportFlag(fs *flag.FlagSet, s *sportValue, name, value, usage string) {
+	*s = sportValue(value)
+	fs.Var(s, name, usage)
+}
+
+/*
+selectDates turns the date, start and end flags of a command into the dates
+	they cover.  A start wins over the date; a start without an end is a
+	single year, month or day.
+*/
+func selectDates(date, start, end string) []time.Time {
+	if len(start) > 0 {
+		return dateslice.DateObjectsToSlice("", start, end)
+	}
+	dates := dateslice.DateStringToSlice(date)
+	if len(dates) == 0 {
+		dates = dateslice.DateObjectsToSlice("", date, date)
+	}
+	return dates
+}
+
+/*
+singleDate turns a date flag into one date, either a name that dateslice
+	understands (today, yesterday, ...) or YYYYMMDD.  The time of day is
+	dropped.
+*/
+func singleDate(value string) (time.Time, error) {
+	dates := dateslice.DateStringToSlice(value)
+	if len(dates) > 1 {
+		return time.Time{}, fmt.Errorf("%s is more than one date", value)
+	}
+	if len(dates) == 0 {
+		return time.ParseInLocation("20060102", value, time.Local)
+	}
+	y, m, d := dates[0].Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local), nil
+}
+
+/*
+parseFeeds reads the comma separated list of feeds given to sync and verify
+*/
+func parseFeeds(value string) ([]string, error) {
+	var feeds []string
+	for _, feed := range strings.Split(strings.ToLower(value), ",") {
+		feed = strings.TrimSpace(feed)
+		if feed != "savant" && feed != "gameday" {
+			return nil, fmt.Errorf("unknown feed %s (expected savant or gameday)", feed)
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, nil
 }
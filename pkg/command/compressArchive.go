@@ -0,0 +1,176 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bauer312/baseball/pkg/config"
+	"github.com/bauer312/baseball/pkg/manifest"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func init() {
+	Register(Registration{
+		Name:     "compress",
+		Synopsis: "Convert the downloaded files in place to gz, zst or plain files",
+		New:      func() Command { return &CompressArchive{} },
+	})
+}
+
+/*
+CompressArchive contains information used to convert the files that have
+	been downloaded from one format to another.  Each file is replaced
+	atomically and the manifest is updated to match, so the converted files
+	are still known to be complete.
+*/
+type CompressArchive struct {
+	output   pathValue
+	feedList string
+	format   compressValue
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (ca *CompressArchive) SetFlags(fs *flag.FlagSet) {
+	pathFlag(fs, &ca.output, "output", "", "Location of the downloaded files")
+	fs.StringVar(&ca.feedList, "feeds", "savant,gameday", "Comma separated data to convert: savant, gameday")
+	compressFlag(fs, &ca.format, "format", util.CompressZstd, "`Format` to convert the files to: none (plain), gz or zst")
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (ca *CompressArchive) Execute(ctx context.Context, args []string) error {
+	feeds, err := parseFeeds(ca.feedList)
+	if err != nil {
+		return &UsageError{Err: err}
+	}
+	if len(ca.output) == 0 {
+		ca.output = pathValue(config.DefaultDataRoot())
+	}
+
+	for _, name := range feeds {
+		if ctx.Err() != nil {
+			return nil
+		}
+		dir := filepath.Join(ca.output.String(), "savant")
+		if name == "gameday" {
+			dir = filepath.Join(ca.output.String(), "gameday", "raw")
+		}
+		ca.convertDir(ctx, name, dir)
+	}
+	return nil
+}
+
+/*
+convertDir converts every downloaded file in a directory that isn't already
+	in the format
+*/
+func (ca *CompressArchive) convertDir(ctx context.Context, feed, dir string) {
+	results := summary.FromContext(ctx)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		slog.Info("Nothing has been downloaded", "dir", dir)
+		return
+	}
+	if err != nil {
+		slog.Error("Unable to read the directory", "dir", dir, "err", err)
+		results.Fail(dir, err)
+		return
+	}
+	downloads, err := manifest.Load(dir)
+	if err != nil {
+		slog.Warn("Unable to read the whole download manifest", "dir", dir, "err", err)
+	}
+	downloaded := make(map[string]manifest.Entry)
+	for _, entry := range downloads.Entries() {
+		downloaded[entry.Path] = entry
+	}
+
+	format := ca.format.String()
+	slog.Info("Converting files", "dir", dir, "format", format)
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, ok := archiveFileDate(feed, entry.Name()); entry.IsDir() || ok == false {
+			continue
+		}
+		if util.CompressionOf(entry.Name()) == format {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		results.Request(path)
+		target, err := convertFile(path, format)
+		if err != nil {
+			slog.Error("Unable to convert file", "path", path, "err", err)
+			results.Fail(path, err)
+			continue
+		}
+		slog.Debug("Converted file", "path", path, "to", target)
+		if e, ok := downloaded[path]; ok {
+			if err = recordConverted(downloads, e, target); err != nil {
+				slog.Error("Unable to update the download manifest", "url", e.URL, "err", err)
+			}
+		}
+		results.Done(path)
+	}
+}
+
+/*
+convertFile rewrites a file in a format and returns its new path.  The file
+	in the old format is only removed once the new one is in place.
+*/
+func convertFile(path, format string) (string, error) {
+	target := util.CompressedPath(util.TrimCompression(path), format)
+	_, err := util.WriteFileCompressed(target, func(w io.Writer) error {
+		r, err := util.OpenFile(path)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(w, r)
+		return err
+	})
+	return target, err
+}
+
+/*
+recordConverted points the manifest entry of a file at its new path.  An
+	entry for a download that failed is left alone, since there is nothing
+	there to keep track of.
+*/
+func recordConverted(downloads *manifest.Manifest, e manifest.Entry, target string) error {
+	if e.Succeeded() == false {
+		return nil
+	}
+	size, sum, err := manifest.HashFile(target)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", target, err)
+	}
+	e.Path, e.Size, e.SHA256 = target, size, sum
+	return downloads.Record(e)
+}
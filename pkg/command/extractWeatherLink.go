@@ -17,72 +17,100 @@
 package command
 
 import (
+	"context"
 	"encoding/csv"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
+func init() {
+	Register(Registration{
+		Name:     "weather",
+		Synopsis: "Extract the columns that link Savant pitches to the weather into a SQL data file",
+		New:      func() Command { return &ExtractWeatherLink{} },
+	})
+}
+
 /*
 ExtractWeatherLink contains information to extract weather linking data
 	from Baseball Savant data
 */
 type ExtractWeatherLink struct {
-	inputDir  string
-	outputDir string
+	inputDir  pathValue
+	outputDir pathValue
 }
 
 /*
 SetFlags creates the flags that are needed for this functionality
 */
-func (ewl *ExtractWeatherLink) SetFlags(fs *flag.FlagSet, cmdMap map[string]*string) {
-	cmdMap["inputDir"] = fs.String("input", ".", "Directory containing Savant CSV files")
-	cmdMap["outputDir"] = fs.String("output", ".", "Directory to write SQL data file")
+func (ewl *ExtractWeatherLink) SetFlags(fs *flag.FlagSet) {
+	pathFlag(fs, &ewl.inputDir, "input", ".", "`Directory` containing Savant CSV files")
+	pathFlag(fs, &ewl.outputDir, "output", ".", "`Directory` to write SQL data file")
 }
 
 /*
 Execute runs the functionality that produces the data needed
 */
-func (ewl *ExtractWeatherLink) Execute(cmdMap map[string]*string) {
-	ewl.inputDir = *cmdMap["inputDir"]
-	ewl.outputDir = *cmdMap["outputDir"]
-
-	files, err := ioutil.ReadDir(ewl.inputDir)
+func (ewl *ExtractWeatherLink) Execute(ctx context.Context, args []string) error {
+	inputDir := ewl.inputDir.String()
+	results := summary.FromContext(ctx)
+	files, err := ioutil.ReadDir(inputDir)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to read the input directory: %w", err)
 	}
 
-	outputFile := filepath.Join(ewl.outputDir, "savant-import.dat")
+	outputFile := filepath.Join(ewl.outputDir.String(), "savant-import.dat")
 	ofp, err := os.Create(outputFile)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to create the output file: %w", err)
 	}
 
 	for _, f := range files {
-		if strings.HasSuffix(strings.ToLower(f.Name()), ".csv") {
-			fmt.Println(f.Name())
-			readSavantCSV(filepath.Join(ewl.inputDir, f.Name()), ofp)
+		if ctx.Err() != nil {
+			break
+		}
+		if strings.HasSuffix(strings.ToLower(util.TrimCompression(f.Name())), ".csv") {
+			slog.Info("Reading file", "file", f.Name())
+			results.Request(f.Name())
+			err = readSavantCSV(filepath.Join(inputDir, f.Name()), ofp)
+			if err != nil {
+				slog.Error("Unable to read file", "file", f.Name(), "err", err)
+				results.Fail(f.Name(), err)
+			} else {
+				results.Done(f.Name())
+			}
 		}
 	}
+	ofp.Close()
 
+	// A file built from only some of the input would be mistaken for the
+	//	whole thing, so don't leave one behind
+	if ctx.Err() != nil || results.Failed() > 0 {
+		os.Remove(outputFile)
+	}
+	return nil
 }
 
-func readSavantCSV(f string, o *os.File) {
-	fp, err := os.Open(f)
+func readSavantCSV(f string, o *os.File) error {
+	fp, err := util.OpenFile(f)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer fp.Close()
 	r := csv.NewReader(fp)
 
 	header, err := r.Read()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	filter := make([]bool, len(header))
 	for i, v := range header {
@@ -102,7 +130,7 @@ func readSavantCSV(f string, o *os.File) {
 			break
 		}
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		firstElement := true
@@ -118,4 +146,5 @@ func readSavantCSV(f string, o *os.File) {
 		}
 		fmt.Fprintln(o, "")
 	}
+	return nil
 }
@@ -0,0 +1,59 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"flag"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+fetchOptions holds the flags shared by every command that downloads data
+*/
+type fetchOptions struct {
+	config  util.FetcherConfig
+	timeout time.Duration
+}
+
+/*
+setFlags creates the flags shared by every command that downloads data
+*/
+func (fo *fetchOptions) setFlags(fs *flag.FlagSet) {
+	fs.Float64Var(&fo.config.Rate, "rate", 1, "Maximum number of requests started per second (0 for no limit)")
+	fs.IntVar(&fo.config.Burst, "burst", 1, "Number of requests that may be started at once after a quiet period")
+	fs.IntVar(&fo.config.Workers, "workers", 4, "Number of requests that may be in flight at the same time")
+	fs.IntVar(&fo.config.Retries, "retries", 3, "Number of times to retry a request after a timeout or server error")
+	fs.DurationVar(&fo.config.Backoff, "backoff", time.Second, "Wait before the first retry; doubles with each retry")
+	fs.DurationVar(&fo.timeout, "timeout", 45*time.Second, "Timeout for a single request")
+}
+
+/*
+newFetcher builds the shared fetcher from the flags and the data source (see
+	util.NewSourceClient)
+*/
+func (fo *fetchOptions) newFetcher(source string) (*util.Fetcher, error) {
+	if fo.config.Rate < 0 || fo.config.Workers < 0 || fo.config.Retries < 0 {
+		return nil, usageErrorf("rate, workers and retries can't be negative")
+	}
+	client, err := util.NewSourceClient(source, fo.timeout)
+	if err != nil {
+		return nil, &UsageError{Err: err}
+	}
+	return util.NewFetcher(client, fo.config), nil
+}
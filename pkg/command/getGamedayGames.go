@@ -17,95 +17,117 @@
 package command
 
 import (
+	"context"
 	"flag"
-	"fmt"
-	"net/http"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/bauer312/baseball/pkg/catalog"
 	"github.com/bauer312/baseball/pkg/datepath"
-	"github.com/bauer312/baseball/pkg/dateslice"
 	"github.com/bauer312/baseball/pkg/filepath"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
+func init() {
+	Register(Registration{
+		Name:     "gameday",
+		Synopsis: "Download the gameday files of every game on each date",
+		New:      func() Command { return &GetGamedayGames{} },
+	})
+}
+
 /*
 GetGamedayGames contains information used to get the gameday
 	data from the MLB website
 */
 type GetGamedayGames struct {
-	date   string
-	start  string
-	end    string
-	output string
-	url    string
+	date     dateValue
+	start    dateValue
+	end      dateValue
+	output   pathValue
+	url      string
+	sport    sportValue
+	source   string
+	files    string
+	force    bool
+	compress compressValue
+	fetch    fetchOptions
 }
 
 /*
 SetFlags creates the flags that are needed for this functionality
 */
-func (ggg *GetGamedayGames) SetFlags(fs *flag.FlagSet, cmdMap map[string]*string) {
-	cmdMap["date"] = fs.String("date", "yesterday", "Retreive data for a specific date (default is yesterday)")
-	cmdMap["start"] = fs.String("start", "", "Retreive data for a date range (YYYYMMDD)")
-	cmdMap["end"] = fs.String("end", "", "Retreive data for a date range (YYYYMMDD)")
-	cmdMap["output"] = fs.String("output", "", "Output location for downloaded files")
-	cmdMap["url"] = fs.String("url", "http://gd2.mlb.com", "Source location of data to download")
-
+func (ggg *GetGamedayGames) SetFlags(fs *flag.FlagSet) {
+	dateFlag(fs, &ggg.date, "date", "yesterday", "Retreive data for a specific `date`")
+	dateFlag(fs, &ggg.start, "start", "", "Retreive data for a date range (`YYYYMMDD`)")
+	dateFlag(fs, &ggg.end, "end", "", "Retreive data for a date range (`YYYYMMDD`)")
+	pathFlag(fs, &ggg.output, "output", "", "Output `directory` for downloaded files")
+	fs.StringVar(&ggg.url, "url", "http://gd2.mlb.com", "Source location of data to download")
+	sportFlag(fs, &ggg.sport, "sport", catalog.DefaultSport, catalog.SportHelp())
+	fs.StringVar(&ggg.source, "source", "http", util.SourceHelp)
+	fs.StringVar(&ggg.files, "files", util.DefaultGameFiles, util.GameFileHelp())
+	fs.BoolVar(&ggg.force, "force", false, "Download files again even if they are already complete")
+	compressFlag(fs, &ggg.compress, "compress", util.CompressNone, util.CompressHelp)
+	ggg.fetch.setFlags(fs)
 }
 
 /*
 Execute runs the functionality that produces the data needed
 */
-func (ggg *GetGamedayGames) Execute(cmdMap map[string]*string) {
-	ggg.date = *cmdMap["date"]
-	ggg.start = *cmdMap["start"]
-	ggg.end = *cmdMap["end"]
-	ggg.output = *cmdMap["output"]
-	ggg.url = *cmdMap["url"]
-
-	var dates []time.Time
-	if len(ggg.start) > 0 {
-		dates = dateslice.DateObjectsToSlice("", ggg.start, ggg.end)
-	} else {
-		dates = dateslice.DateStringToSlice(ggg.date)
-		if len(dates) == 0 {
-			dates = dateslice.DateObjectsToSlice("", ggg.date, ggg.date)
-		}
+func (ggg *GetGamedayGames) Execute(ctx context.Context, args []string) error {
+	dates := selectDates(ggg.date.String(), ggg.start.String(), ggg.end.String())
+	return ggg.downloadDates(ctx, dates)
+}
+
+/*
+downloadDates downloads the selected files of every game on each of the
+	dates, skipping those that are already complete
+*/
+func (ggg *GetGamedayGames) downloadDates(ctx context.Context, dates []time.Time) error {
+	files, err := util.SelectGameFiles(ggg.files)
+	if err != nil {
+		return &UsageError{Err: err}
 	}
 
-	client := http.Client{Timeout: (45 * time.Second)}
-	var datePaths datepath.DatePath
+	client, err := ggg.fetch.newFetcher(ggg.source)
+	if err != nil {
+		return err
+	}
+	datePaths := datepath.DatePath{Files: files}
 
 	datePaths.Init()
-	go datePaths.ChannelListener(&client)
+	go datePaths.ChannelListener(ctx, client)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	go printFilePath(&wg, datePaths.FilePath, ggg.output)
+	go printFilePath(ctx, &wg, datePaths.FilePath, ggg.output.String(), ggg.force, ggg.compress.String(), client)
 
 	for i, dt := range dates {
-		fmt.Printf("Downloading data for [%d] %s (%s)\n",
-			i+1, dt.Format("20060102"), dateToPath(ggg.url, dt))
-		datePaths.DatePath <- dateToPath(ggg.url, dt)
+		if ctx.Err() != nil {
+			break
+		}
+		slog.Info("Downloading data", "index", i+1, "date", dt.Format("20060102"), "url", dateToPath(ggg.url, ggg.sport.String(), dt))
+		summary.FromContext(ctx).Add(summary.DatesRequested, 1)
+		datePaths.DatePath <- dateToPath(ggg.url, ggg.sport.String(), dt)
 	}
 
 	datePaths.Done()
 
 	wg.Wait()
+	return nil
 }
 
-func dateToPath(baseURL string, date time.Time) string {
-	year := date.Year()
-	month := date.Month()
-	day := date.Day()
-	return fmt.Sprintf("%s/components/game/mlb/year_%04d/month_%02d/day_%02d", baseURL, year, month, day)
+func dateToPath(baseURL, sport string, date time.Time) string {
+	return catalog.DayPath(baseURL, sport, date)
 }
 
-func printFilePath(wg *sync.WaitGroup, paths chan string, output string) {
-	client := http.Client{Timeout: (45 * time.Second)}
-	var filePaths filepath.FilePath
+func printFilePath(ctx context.Context, wg *sync.WaitGroup, paths chan string, output string, force bool, compression string, client *util.Fetcher) {
+	filePaths := filepath.FilePath{Force: force, Workers: client.Workers(), Compression: compression}
 	filePaths.Init(output)
-	go filePaths.ChannelListener(&client)
+	go filePaths.ChannelListener(ctx, client)
 	for path := range paths {
 		//fmt.Printf("\t%s\n", path)
 		filePaths.FilePath <- path
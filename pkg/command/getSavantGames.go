@@ -17,110 +17,179 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
+	"log/slog"
 	"os"
-	"os/user"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/bauer312/baseball/pkg/dateslice"
+	"github.com/bauer312/baseball/pkg/config"
+	"github.com/bauer312/baseball/pkg/manifest"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
+func init() {
+	Register(Registration{
+		Name:     "savant",
+		Synopsis: "Download the Statcast pitches of each date from Baseball Savant",
+		New:      func() Command { return &GetSavantGames{} },
+	})
+}
+
 /*
 GetSavantGames contains information used to get the Baseball Savant
 	data from the Baseball Savant website
 */
 type GetSavantGames struct {
-	date   string
-	start  string
-	end    string
-	output string
-	url    string
+	date       dateValue
+	start      dateValue
+	end        dateValue
+	output     pathValue
+	url        string
+	force      bool
+	compress   compressValue
+	gameTypes  string
+	playerType string
+	team       string
+	pitchTypes string
+	batters    string
+	pitchers   string
+	fetch      fetchOptions
+	query      SavantQuery
+	rowCap     int
 }
 
 /*
 SetFlags creates the flags that are needed for this functionality
 */
-func (gsg *GetSavantGames) SetFlags(fs *flag.FlagSet, cmdMap map[string]*string) {
-	cmdMap["date"] = fs.String("date", "yesterday", "Retreive data for a specific date (default is yesterday)")
-	cmdMap["start"] = fs.String("start", "", "Retreive data for a date range (YYYYMMDD)")
-	cmdMap["end"] = fs.String("end", "", "Retreive data for a date range (YYYYMMDD)")
-	cmdMap["output"] = fs.String("output", "", "Output location for downloaded files")
-	cmdMap["url"] = fs.String("url", "https://baseballsavant.mlb.com", "Source location of data to download")
-
+func (gsg *GetSavantGames) SetFlags(fs *flag.FlagSet) {
+	dateFlag(fs, &gsg.date, "date", "yesterday", "Retreive data for a specific `date`")
+	dateFlag(fs, &gsg.start, "start", "", "Retreive data for a date range (`YYYYMMDD`)")
+	dateFlag(fs, &gsg.end, "end", "", "Retreive data for a date range (`YYYYMMDD`)")
+	pathFlag(fs, &gsg.output, "output", "", "Output `directory` for downloaded files")
+	fs.StringVar(&gsg.url, "url", "https://baseballsavant.mlb.com", "Source location of data to download")
+	fs.BoolVar(&gsg.force, "force", false, "Download files again even if they are already complete")
+	compressFlag(fs, &gsg.compress, "compress", util.CompressNone, util.CompressHelp)
+	fs.StringVar(&gsg.gameTypes, "game-types", "R", "Comma separated game types: R (regular season), F, D, L, W (postseason rounds) and S (spring training)")
+	fs.StringVar(&gsg.playerType, "player-type", "pitcher", "Search from the side of the pitcher or the batter")
+	fs.StringVar(&gsg.team, "team", "", "Only include a single team (LAA, NYY, ...)")
+	fs.StringVar(&gsg.pitchTypes, "pitch-types", "", "Comma separated pitch types (FF, SL, CH, ...)")
+	fs.StringVar(&gsg.batters, "batter", "", "Comma separated MLBAM ids of batters")
+	fs.StringVar(&gsg.pitchers, "pitcher", "", "Comma separated MLBAM ids of pitchers")
+	gsg.fetch.setFlags(fs)
 }
 
 /*
 Execute runs the functionality that produces the data needed
 */
-func (gsg *GetSavantGames) Execute(cmdMap map[string]*string) {
-	gsg.date = *cmdMap["date"]
-	gsg.start = *cmdMap["start"]
-	gsg.end = *cmdMap["end"]
-	gsg.output = *cmdMap["output"]
-	gsg.url = *cmdMap["url"]
-
-	var dates []time.Time
-	if len(gsg.start) > 0 {
-		dates = dateslice.DateObjectsToSlice("", gsg.start, gsg.end)
-	} else {
-		dates = dateslice.DateStringToSlice(gsg.date)
-		if len(dates) == 0 {
-			dates = dateslice.DateObjectsToSlice("", gsg.date, gsg.date)
-		}
+func (gsg *GetSavantGames) Execute(ctx context.Context, args []string) error {
+	gsg.rowCap = savantRowCap
+	gsg.query = SavantQuery{
+		BaseURL:    gsg.url,
+		GameTypes:  splitList(gsg.gameTypes),
+		PlayerType: strings.ToLower(gsg.playerType),
+		Team:       strings.ToUpper(gsg.team),
+		PitchTypes: splitList(gsg.pitchTypes),
+		Batters:    splitList(gsg.batters),
+		Pitchers:   splitList(gsg.pitchers),
+	}
+	if err := gsg.query.Validate(); err != nil {
+		return &UsageError{Err: err}
 	}
 
-	fullOutputPath := validateOutput(gsg.output)
+	dates := selectDates(gsg.date.String(), gsg.start.String(), gsg.end.String())
+	return gsg.downloadDates(ctx, dates)
+}
 
-	for i, dt := range dates {
-		targetURL := savantdateToPath(gsg.url, dt)
-		fmt.Printf("Downloading data for [%d] %s (%s)\n", i+1, dt.Format("20060102"), targetURL)
-		savantdownloadFile(targetURL, filepath.Join(fullOutputPath, dt.Format("20060102")+".csv"))
+/*
+downloadDates downloads each of the dates into the savant directory of the
+	output location, skipping those that are already complete
+*/
+func (gsg *GetSavantGames) downloadDates(ctx context.Context, dates []time.Time) error {
+	summary.FromContext(ctx).Add(summary.DatesRequested, int64(len(dates)))
+
+	fullOutputPath := validateOutput(gsg.output.String())
+
+	downloads, err := manifest.Load(fullOutputPath)
+	if err != nil {
+		return fmt.Errorf("unable to load the download manifest in %s: %w", fullOutputPath, err)
 	}
+
+	fetcher, err := gsg.fetch.newFetcher("http")
+	if err != nil {
+		return err
+	}
+
+	// Each worker downloads one date at a time; the fetcher keeps the
+	//	combined request rate polite
+	dateIndexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < fetcher.Workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range dateIndexes {
+				gsg.downloadDate(ctx, i, dates[i], fullOutputPath, downloads, fetcher)
+			}
+		}()
+	}
+	for i := range dates {
+		select {
+		case dateIndexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(dateIndexes)
+	wg.Wait()
+	return nil
 }
 
-func savantdateToPath(baseURL string, date time.Time) string {
-	year := date.Year()
-	month := date.Month()
-	day := date.Day()
-	return fmt.Sprintf("%s/statcast_search/csv?all=true&hfPT=&hfAB=&hfBBT=&hfPR=&hfZ=&stadium=&hfBBL=&hfNewZones=&hfGT=R|&hfC=&hfSea=%04d|&hfSit=&player_type=pitcher&hfOuts=&opponent=&pitcher_throws=&batter_stands=&hfSA=&game_date_gt=%04d-%02d-%02d&game_date_lt=%04d-%02d-%02d&hfInfield=&team=&position=&hfOutfield=&hfRO=&home_road=&hfFlag=&hfPull=&metric_1=&hfInn=&min_pitches=0&min_results=0&group_by=name&sort_col=pitches&player_event_sort=h_launch_speed&sort_order=desc&min_pas=0&type=details&", baseURL, year, year, month, day, year, month, day)
+func (gsg *GetSavantGames) downloadDate(ctx context.Context, i int, dt time.Time, fullOutputPath string, downloads *manifest.Manifest, client util.Getter) {
+	query := gsg.query.ForDate(dt)
+	targetURL := query.URL()
+	if gsg.force == false && downloads.Complete(targetURL) {
+		slog.Info("Skipping date, it has already been downloaded", "index", i+1, "date", dt.Format("20060102"))
+		return
+	}
+	results := summary.FromContext(ctx)
+	results.Request(dt.Format("20060102"))
+	slog.Info("Downloading data", "index", i+1, "date", dt.Format("20060102"), "url", targetURL)
+	target := util.CompressedPath(filepath.Join(fullOutputPath, dt.Format("20060102")+".csv"), gsg.compress.String())
+	err := gsg.savantdownloadDate(ctx, query, target, downloads, client)
+	if err != nil {
+		slog.Error("Unable to download data", "date", dt.Format("20060102"), "err", err)
+		results.Fail(dt.Format("20060102"), err)
+		return
+	}
+	results.Done(dt.Format("20060102"))
 }
 
-func savantdownloadFile(url, target string) {
-	fmt.Printf("Target: %s\n", target)
-	client := http.Client{Timeout: (45 * time.Second)}
-	resp, err := client.Get(url)
+/*
+recordFailedDownload notes a download that did not finish so that the next
+	run will try it again
+*/
+func recordFailedDownload(downloads *manifest.Manifest, url, target string) {
+	err := downloads.Record(manifest.Entry{URL: url, Path: target})
 	if err != nil {
-		fmt.Println(err.Error())
-	} else {
-		defer resp.Body.Close()
-		f, err := os.OpenFile(target, os.O_CREATE|os.O_APPEND|os.O_RDWR, os.ModePerm)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		defer f.Close()
-		io.Copy(f, resp.Body)
+		slog.Error("Unable to update the download manifest", "url", url, "err", err)
 	}
 }
 
 func validateOutput(output string) string {
 
 	if len(output) == 0 {
-		usr, err := user.Current()
-		if err != nil {
-			fmt.Println("Unable to determine user storage location")
-		}
-		output = filepath.Join(usr.HomeDir, "baseball")
+		output = config.DefaultDataRoot()
 	}
 	basePath := filepath.Join(output, "savant/")
 	err := os.MkdirAll(basePath, 0740)
 	if err != nil {
-		fmt.Println("Unable to validate storage location")
+		slog.Error("Unable to validate storage location", "path", basePath, "err", err)
 	}
-	fmt.Printf("Storage Location: %s\n", basePath)
+	slog.Info("Storage location", "path", basePath)
 	return basePath
 }
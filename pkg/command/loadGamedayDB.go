@@ -17,62 +17,96 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"strings"
 
 	"github.com/bauer312/baseball/pkg/db"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
+func init() {
+	Register(Registration{
+		Name:     "loadgameday",
+		Synopsis: "Load a directory of Gameday inning_all files into the pitch, runner and pickoff tables",
+		New:      func() Command { return &LoadGamedayData{} },
+	})
+}
+
 /*
 LoadGamedayData contains information to save
 	Baseball Savant data
 */
 type LoadGamedayData struct {
-	inputDir string
+	inputDir pathValue
+	dsn      string
 }
 
 /*
 SetFlags creates the flags that are needed for this functionality
 */
-func (ewl *LoadGamedayData) SetFlags(fs *flag.FlagSet, cmdMap map[string]*string) {
-	cmdMap["inputDir"] = fs.String("input", ".", "Directory containing Gameday XML files")
+func (ewl *LoadGamedayData) SetFlags(fs *flag.FlagSet) {
+	pathFlag(fs, &ewl.inputDir, "input", ".", "`Directory` containing Gameday XML files")
+	fs.StringVar(&ewl.dsn, "db", "", "Database connection string (default from the config file)")
 }
 
 /*
 Execute runs the functionality that produces the data needed
 */
-func (ewl *LoadGamedayData) Execute(cmdMap map[string]*string) {
-	ewl.inputDir = *cmdMap["inputDir"]
-
-	files, err := ioutil.ReadDir(ewl.inputDir)
+func (ewl *LoadGamedayData) Execute(ctx context.Context, args []string) error {
+	inputDir := ewl.inputDir.String()
+	files, err := ioutil.ReadDir(inputDir)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to read the input directory: %w", err)
 	}
 
+	var paths []string
+	for _, f := range files {
+		if strings.HasSuffix(strings.ToLower(util.TrimCompression(f.Name())), "_inning_all.xml") {
+			paths = append(paths, filepath.Join(inputDir, f.Name()))
+		}
+	}
+	return loadGamedayFiles(ctx, ewl.dsn, paths)
+}
+
+/*
+loadGamedayFiles loads inning_all files into mlb_gameday and the other tables
+	filled from them (see db.LoadGamedayXML), creating the tables if they
+	aren't there yet
+*/
+func loadGamedayFiles(ctx context.Context, dsn string, paths []string) error {
+	results := summary.FromContext(ctx)
 	bbdb := db.BaseballDB{}
-	err = bbdb.ConnectToPostgres("BBALL")
+	err := bbdb.Connect(dsn)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to connect to the database: %w", err)
 	}
 	defer bbdb.Close()
 
-	err = bbdb.ConfirmGamedayMaster()
+	err = bbdb.ConfirmGamedayMaster(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to create the table: %w", err)
 	}
 
-	for _, f := range files {
-		if strings.HasSuffix(strings.ToLower(f.Name()), "_inning_all.xml") {
-			fmt.Println(f.Name())
-			err = bbdb.LoadGamedayXML(filepath.Join(ewl.inputDir, f.Name()))
-			if err != nil {
-				log.Println(err)
-			}
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		name := filepath.Base(path)
+		slog.Info("Loading file", "file", name)
+		results.Request(name)
+		err = bbdb.LoadGamedayXML(ctx, path)
+		if err != nil {
+			slog.Error("Unable to load file", "file", name, "err", err)
+			results.Fail(name, err)
+		} else {
+			results.Done(name)
 		}
 	}
-
+	return nil
 }
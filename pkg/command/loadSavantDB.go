@@ -17,62 +17,95 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"strings"
 
 	"github.com/bauer312/baseball/pkg/db"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
+func init() {
+	Register(Registration{
+		Name:     "loadsavant",
+		Synopsis: "Load a directory of Savant CSV files into mlb_savant",
+		New:      func() Command { return &LoadSavantData{} },
+	})
+}
+
 /*
 LoadSavantData contains information to save
 	Baseball Savant data
 */
 type LoadSavantData struct {
-	inputDir string
+	inputDir pathValue
+	dsn      string
 }
 
 /*
 SetFlags creates the flags that are needed for this functionality
 */
-func (ewl *LoadSavantData) SetFlags(fs *flag.FlagSet, cmdMap map[string]*string) {
-	cmdMap["inputDir"] = fs.String("input", ".", "Directory containing Savant CSV files")
+func (ewl *LoadSavantData) SetFlags(fs *flag.FlagSet) {
+	pathFlag(fs, &ewl.inputDir, "input", ".", "`Directory` containing Savant CSV files")
+	fs.StringVar(&ewl.dsn, "db", "", "Database connection string (default from the config file)")
 }
 
 /*
 Execute runs the functionality that produces the data needed
 */
-func (ewl *LoadSavantData) Execute(cmdMap map[string]*string) {
-	ewl.inputDir = *cmdMap["inputDir"]
-
-	files, err := ioutil.ReadDir(ewl.inputDir)
+func (ewl *LoadSavantData) Execute(ctx context.Context, args []string) error {
+	inputDir := ewl.inputDir.String()
+	files, err := ioutil.ReadDir(inputDir)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to read the input directory: %w", err)
 	}
 
+	var paths []string
+	for _, f := range files {
+		if strings.HasSuffix(strings.ToLower(util.TrimCompression(f.Name())), ".csv") {
+			paths = append(paths, filepath.Join(inputDir, f.Name()))
+		}
+	}
+	return loadSavantFiles(ctx, ewl.dsn, paths)
+}
+
+/*
+loadSavantFiles loads Savant CSV files into mlb_savant.  A file that fails
+	to load leaves nothing behind, so it can simply be loaded again.
+*/
+func loadSavantFiles(ctx context.Context, dsn string, paths []string) error {
+	results := summary.FromContext(ctx)
 	bbdb := db.BaseballDB{}
-	err = bbdb.ConnectToPostgres("BBALL")
+	err := bbdb.Connect(dsn)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to connect to the database: %w", err)
 	}
 	defer bbdb.Close()
 
-	err = bbdb.ConfirmSavantMaster()
+	err = bbdb.ConfirmSavantMaster(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to create the table: %w", err)
 	}
 
-	for _, f := range files {
-		if strings.HasSuffix(strings.ToLower(f.Name()), ".csv") {
-			fmt.Println(f.Name())
-			err = bbdb.LoadSavantCSV(filepath.Join(ewl.inputDir, f.Name()))
-			if err != nil {
-				log.Println(err)
-			}
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		name := filepath.Base(path)
+		slog.Info("Loading file", "file", name)
+		results.Request(name)
+		err = bbdb.LoadSavantCSV(ctx, path)
+		if err != nil {
+			slog.Error("Unable to load file", "file", name, "err", err)
+			results.Fail(name, err)
+		} else {
+			results.Done(name)
 		}
 	}
-
+	return nil
 }
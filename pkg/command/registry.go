@@ -0,0 +1,170 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bauer312/baseball/pkg/config"
+)
+
+/*
+Registration describes a command to the baseball program, which finds it by
+	name.  Every command registers itself from an init function, so adding a
+	command doesn't mean changing the program.
+*/
+type Registration struct {
+	// Name is what is typed on the command line, such as savant
+	Name string
+	// Synopsis is a one line description for the list of commands
+	Synopsis string
+	// Args describes the arguments that follow the flags, such as <command>;
+	//	a command without it takes no arguments
+	Args string
+	// Complete lists the words that may be given as arguments, for the
+	//	shell completion scripts
+	Complete func() []string
+	// New creates the command
+	New func() Command
+	// NoSummary is set for commands that don't do the work the summary counts
+	NoSummary bool
+	// UntilStopped is set for commands that run until they are interrupted,
+	//	which is how they are meant to finish
+	UntilStopped bool
+}
+
+var registry = make(map[string]Registration)
+
+/*
+Register adds a command.  It panics if the name has already been taken.
+*/
+func Register(r Registration) {
+	if _, ok := registry[r.Name]; ok {
+		panic("command: " + r.Name + " is registered twice")
+	}
+	registry[r.Name] = r
+}
+
+/*
+Lookup finds a registered command by name
+*/
+func Lookup(name string) (Registration, bool) {
+	r, ok := registry[name]
+	return r, ok
+}
+
+/*
+Registered returns every registered command, in order of name
+*/
+func Registered() []Registration {
+	commands := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		commands = append(commands, r)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+/*
+Globals holds the flags that every command accepts
+*/
+type Globals struct {
+	Config    string
+	LogFormat string
+	LogLevel  string
+}
+
+/*
+NewFlagSet creates the command and a flag set holding the global flags and the
+	flags of the command.  Parse errors are returned rather than ending the
+	program, and -h prints the help of the command.
+*/
+func NewFlagSet(r Registration, g *Globals) (*flag.FlagSet, Command) {
+	fs := flag.NewFlagSet("baseball "+r.Name, flag.ContinueOnError)
+	fs.Var((*pathValue)(&g.Config), "config", "Config `file` (default "+config.DefaultPath()+")")
+	fs.StringVar(&g.LogFormat, "log-format", "text", "Format of the log written to stderr (text or json)")
+	fs.StringVar(&g.LogLevel, "log-level", "info", "Least important log messages to write (debug, info, warn or error)")
+	cmd := r.New()
+	cmd.SetFlags(fs)
+	fs.Usage = func() {
+		PrintUsage(fs.Output(), r, fs)
+	}
+	return fs, cmd
+}
+
+/*
+ParseArgs parses the flags of a command and returns the arguments among them,
+	so that flags may come after the arguments as well as before them:
+		baseball config show -config other.toml
+*/
+func ParseArgs(fs *flag.FlagSet, arguments []string) ([]string, error) {
+	var args []string
+	for {
+		if err := fs.Parse(arguments); err != nil {
+			return nil, err
+		}
+		// Everything after -- is an argument
+		if n := len(arguments) - len(fs.Args()); n > 0 && arguments[n-1] == "--" {
+			return append(args, fs.Args()...), nil
+		}
+		arguments = fs.Args()
+		if len(arguments) == 0 {
+			return args, nil
+		}
+		args = append(args, arguments[0])
+		arguments = arguments[1:]
+	}
+}
+
+/*
+PrintUsage writes the help of a single command
+*/
+func PrintUsage(w io.Writer, r Registration, fs *flag.FlagSet) {
+	args := ""
+	if len(r.Args) > 0 {
+		args = " " + r.Args
+	}
+	fmt.Fprintf(w, "Usage: baseball %s%s [flags]\n\n%s\n\nFlags:\n", r.Name, args, r.Synopsis)
+	out := fs.Output()
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fs.SetOutput(out)
+}
+
+/*
+PrintCommands writes the list of commands
+*/
+func PrintCommands(w io.Writer) {
+	fmt.Fprintln(w, "Usage: baseball <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Available Commands")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, r := range Registered() {
+		name := r.Name
+		if len(r.Args) > 0 {
+			name += " " + r.Args
+		}
+		fmt.Fprintf(tw, "\t%s\t%s\n", name, r.Synopsis)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'baseball help <command>' for the flags of a command.")
+}
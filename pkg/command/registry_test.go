@@ -0,0 +1,75 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRegisteredCommands(t *testing.T) {
+	// Creating the flag set panics if a command redefines a flag
+	for _, r := range Registered() {
+		fs, cmd := NewFlagSet(r, &Globals{})
+		if cmd == nil || len(r.Synopsis) == 0 {
+			t.Errorf("%s is missing its command or synopsis", r.Name)
+		}
+		if fs.Lookup("config") == nil || fs.Lookup("log-level") == nil {
+			t.Errorf("%s is missing the global flags", r.Name)
+		}
+	}
+
+	var parseTest = []struct {
+		Command      string
+		Args         string
+		ExpectedArgs string
+		ExpectedErr  bool
+	}{
+		{"config", "show -config other.toml", "show", false},
+		{"config", "-log-level debug show", "show", false},
+		{"help", "-- -savant", "-savant", false},
+		{"savant", "-date 20190610 -force -workers 2", "", false},
+		{"savant", "-start 201906 -end 2019", "", false},
+		{"savant", "-date 2019061", "", true},
+		{"savant", "-date someday", "", true},
+		{"gameday", "-workers many", "", true},
+		{"watch", "-interval 30", "", true},
+		{"sync", "-compress zstd", "", false},
+		{"compress", "-format lz4", "", true},
+	}
+	for _, ex := range parseTest {
+		r, ok := Lookup(ex.Command)
+		if ok == false {
+			t.Fatalf("%s is not registered", ex.Command)
+		}
+		fs, _ := NewFlagSet(r, &Globals{})
+		fs.SetOutput(io.Discard)
+		args, err := ParseArgs(fs, strings.Fields(ex.Args))
+		if (err != nil) != ex.ExpectedErr {
+			t.Errorf("%s %s: unexpected error %v", ex.Command, ex.Args, err)
+		}
+		if err == nil && strings.Join(args, " ") != ex.ExpectedArgs {
+			t.Errorf("%s %s: expected arguments %q, got %q", ex.Command, ex.Args, ex.ExpectedArgs, args)
+		}
+	}
+
+	if ExitCode(nil) != ExitSuccess || ExitCode(errors.New("failed")) != ExitFailure || ExitCode(usageErrorf("bad flag")) != ExitUsage {
+		t.Errorf("Exit codes do not match the errors")
+	}
+}
@@ -0,0 +1,206 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/bauer312/baseball/pkg/catalog"
+	"github.com/bauer312/baseball/pkg/pipelinestage"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func init() {
+	Register(Registration{
+		Name:     "pipeline",
+		Synopsis: "Turn the scoreboards, boxscores and rosters of a date range into records",
+		New:      func() Command { return &RunPipeline{} },
+	})
+}
+
+/*
+RunPipeline contains information used to run the gameday scoreboard
+	pipeline from a date range all the way to a data sink.  The scoreboard
+	of each date sends its games to the boxscore (bis_boxscore.xml) and
+	roster (players.xml, batters/ and pitchers/) stages.  The DateFile,
+	GameFile and GameEventsFile stages still write the older delimited
+	output and are not part of the pipeline.
+*/
+type RunPipeline struct {
+	date   dateValue
+	start  dateValue
+	end    dateValue
+	sink   string
+	output pathValue
+	url    string
+	sport  sportValue
+	source string
+	dsn    string
+	fetch  fetchOptions
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (rp *RunPipeline) SetFlags(fs *flag.FlagSet) {
+	dateFlag(fs, &rp.date, "date", "yesterday", "Process data for a specific `date`")
+	dateFlag(fs, &rp.start, "start", "", "Process data for a date range (`YYYYMMDD`)")
+	dateFlag(fs, &rp.end, "end", "", "Process data for a date range (`YYYYMMDD`)")
+	fs.StringVar(&rp.sink, "sink", "screen", "Destination of the records (screen, file or db)")
+	pathFlag(fs, &rp.output, "output", "", "Output `directory` for the file sink")
+	fs.StringVar(&rp.url, "url", "http://gd2.mlb.com", "Source location of data to download")
+	sportFlag(fs, &rp.sport, "sport", catalog.DefaultSport, catalog.SportHelp())
+	fs.StringVar(&rp.source, "source", "http", util.SourceHelp)
+	fs.StringVar(&rp.dsn, "db", "", "Database connection string for the db sink (default from the config file)")
+	rp.fetch.setFlags(fs)
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (rp *RunPipeline) Execute(ctx context.Context, args []string) error {
+	rp.sink = strings.ToLower(rp.sink)
+
+	dateRange, ok := rp.dateRange()
+	if ok == false {
+		return usageErrorf("unable to determine the dates to process from %s", rp.date)
+	}
+
+	client, err := rp.fetch.newFetcher(rp.source)
+	if err != nil {
+		return err
+	}
+
+	// Wire up the stages.  Each stage reads from the output channel of the
+	//	stage before it, so they have to be initialized in order.
+	dateStage := &pipelinestage.DateToPath{
+		DataInput: make(chan pipelinestage.DateInputParameters),
+		BaseURL:   rp.url,
+		Sport:     rp.sport.String(),
+	}
+	dateStage.Init(ctx)
+
+	scoreboardStage := &pipelinestage.ScoreBoardFile{
+		DataInput: dateStage.DataOutput,
+		Client:    client,
+	}
+	scoreboardStage.Init(ctx)
+
+	boxscoreStage := &pipelinestage.BoxscoreFile{
+		DataInput: make(chan string),
+		BaseURL:   rp.url,
+		Client:    client,
+	}
+	boxscoreStage.Init(ctx)
+
+	playersStage := &pipelinestage.PlayersFile{
+		DataInput: make(chan pipelinestage.GameDirectory),
+		BaseURL:   rp.url,
+		Client:    client,
+	}
+	playersStage.Init(ctx)
+
+	sinkStage, err := newSinkStage(rp.sink, rp.output.String(), rp.dsn,
+		scoreboardStage.DataOutput, boxscoreStage.DataOutput, playersStage.DataOutput)
+	if err == nil {
+		err = sinkStage.Init(ctx)
+	}
+	if err != nil {
+		dateStage.Abort()
+		scoreboardStage.Abort()
+		boxscoreStage.Abort()
+		playersStage.Abort()
+		return err
+	}
+
+	// Every game directory goes to both the boxscore and the roster stage
+	var gameWG sync.WaitGroup
+	gameWG.Add(1)
+	go func() {
+		defer gameWG.Done()
+		for game := range scoreboardStage.GameFileOutout {
+			select {
+			case boxscoreStage.DataInput <- game.Path:
+			case <-ctx.Done():
+			}
+			select {
+			case playersStage.DataInput <- game:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	stages := []pipelinestage.Controller{dateStage, scoreboardStage, boxscoreStage, playersStage, sinkStage}
+	for _, stage := range stages {
+		go stage.Run()
+	}
+
+	slog.Info("Processing dates", "beg", dateRange.Beg, "end", dateRange.End, "sink", rp.sink)
+	select {
+	case dateStage.DataInput <- dateRange:
+	case <-ctx.Done():
+	}
+
+	// Shut down from the top of the pipeline so that every stage has
+	//	drained its input before the next one is told to stop.  When the
+	//	context has been cancelled, every stage has already given up on its
+	//	work, so this finishes right away.
+	dateStage.Stop()
+	scoreboardStage.Stop()
+	close(scoreboardStage.GameFileOutout)
+	gameWG.Wait()
+	boxscoreStage.Stop()
+	playersStage.Stop()
+	sinkStage.Stop()
+	return nil
+}
+
+/*
+dateRange turns the date flags into the first and last dates to process, the
+	same way as the other commands (see selectDates)
+*/
+func (rp *RunPipeline) dateRange() (pipelinestage.DateInputParameters, bool) {
+	dates := selectDates(rp.date.String(), rp.start.String(), rp.end.String())
+	if len(dates) == 0 {
+		return pipelinestage.DateInputParameters{}, false
+	}
+	return pipelinestage.DateInputParameters{
+		Beg: dates[0].Format("20060102"),
+		End: dates[len(dates)-1].Format("20060102"),
+	}, true
+}
+
+/*
+newSinkStage creates the pipeline stage that stores the records it receives
+	from any of its inputs: screen, file or db
+*/
+func newSinkStage(sink, output, dsn string, inputs ...chan string) (pipelinestage.Controller, error) {
+	switch sink {
+	case "screen":
+		return &pipelinestage.ScreenOutput{DataInput: inputs}, nil
+	case "file":
+		return &pipelinestage.FileOutput{DataInput: inputs, BasePath: output}, nil
+	case "db":
+		return &pipelinestage.DatabaseOutput{DataInput: inputs, DSN: dsn}, nil
+	}
+	return nil, fmt.Errorf("unknown sink %s", sink)
+}
@@ -0,0 +1,98 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func TestRunPipeline(t *testing.T) {
+	// Two days of the fixture tree, each with one finished game
+	var gameRequests int32
+	server := fixtures.New(fixtures.Default())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/game.xml") {
+			atomic.AddInt32(&gameRequests, 1)
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	dsn := "sqlite:" + filepath.Join(t.TempDir(), "baseball.db")
+	rp := &RunPipeline{}
+	fs := flag.NewFlagSet("pipeline", flag.ContinueOnError)
+	rp.SetFlags(fs)
+	err := fs.Parse([]string{"-start", "20190610", "-end", "20190611", "-sink", "db", "-db", dsn, "-url", ts.URL, "-workers", "1", "-rate", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := summary.New("pipeline")
+	ctx, cancel := context.WithTimeout(summary.NewContext(context.Background(), results), 30*time.Second)
+	defer cancel()
+	if err := rp.Execute(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("The pipeline did not finish")
+	}
+	if results.Failed() != 0 {
+		t.Errorf("Expected no failures, got %d", results.Failed())
+	}
+	// The game_pk of the umpire assignments comes from the scoreboard
+	if gameRequests != 0 {
+		t.Errorf("Expected game.xml not to be read, it was read %d times", gameRequests)
+	}
+
+	db, err := util.GetDBConnection(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var countTest = []struct {
+		Query    string
+		Expected int
+	}{
+		{"SELECT count(*) FROM GameRecord;", 2},
+		{"SELECT count(*) FROM GameStatusRecord WHERE status = 'Final';", 2},
+		{"SELECT count(*) FROM BattingLineRecord;", 8},
+		{"SELECT count(*) FROM PitchingLineRecord;", 4},
+		{"SELECT count(DISTINCT id) FROM PlayerRecord;", 6},
+		{"SELECT count(*) FROM GameUmpireAssignmentRecord;", 8},
+	}
+	for _, ex := range countTest {
+		var count int
+		if err := db.QueryRowContext(ctx, ex.Query).Scan(&count); err != nil {
+			t.Errorf("%s: %s", ex.Query, err)
+			continue
+		}
+		if count != ex.Expected {
+			t.Errorf("%s: expected %d, got %d", ex.Query, ex.Expected, count)
+		}
+	}
+}
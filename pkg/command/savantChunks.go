@@ -0,0 +1,241 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/bauer312/baseball/pkg/manifest"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+savantRowCap is the largest number of rows the Statcast search will return for
+	a single query.  A response that reaches it has probably been truncated.
+*/
+const savantRowCap = 40000
+
+/*
+savantTeams are the team codes accepted by the Statcast search
+*/
+var savantTeams = []string{
+	"LAA", "HOU", "OAK", "TOR", "ATL", "MIL", "STL", "CHC", "AZ", "LAD",
+	"SF", "CLE", "SEA", "MIA", "NYM", "WSH", "BAL", "SD", "PHI", "PIT",
+	"TEX", "TB", "BOS", "CIN", "COL", "KC", "DET", "MIN", "CWS", "NYY",
+}
+
+/*
+savantChunk is one piece of a query for a single date
+*/
+type savantChunk struct {
+	team       string
+	playerType string
+	throws     string
+	stands     string
+}
+
+/*
+split returns the smaller queries that cover a truncated chunk.  The whole day
+	is split by team, a team by the hand the pitcher throws with, and that by
+	the side the batter stands on.  Each split covers every pitch of the
+	chunk exactly once.  A chunk that can't be split any further returns
+	nothing.
+*/
+func (sc savantChunk) split() []savantChunk {
+	if len(sc.team) == 0 {
+		chunks := make([]savantChunk, len(savantTeams))
+		for i, team := range savantTeams {
+			chunks[i] = savantChunk{team: team, playerType: sc.playerType}
+		}
+		return chunks
+	}
+	if len(sc.throws) == 0 {
+		return []savantChunk{
+			{team: sc.team, playerType: sc.playerType, throws: "R"},
+			{team: sc.team, playerType: sc.playerType, throws: "L"},
+		}
+	}
+	if len(sc.stands) == 0 {
+		return []savantChunk{
+			{team: sc.team, playerType: sc.playerType, throws: sc.throws, stands: "R"},
+			{team: sc.team, playerType: sc.playerType, throws: sc.throws, stands: "L"},
+		}
+	}
+	return nil
+}
+
+/*
+apply narrows a query down to the chunk
+*/
+func (sc savantChunk) apply(query SavantQuery) SavantQuery {
+	query.Team = sc.team
+	query.PlayerType = sc.playerType
+	query.PitcherThrows = sc.throws
+	query.BatterStands = sc.stands
+	return query
+}
+
+/*
+savantPitches is the merged result of all of the chunks for a single date,
+	with each pitch kept only once
+*/
+type savantPitches struct {
+	header  []string
+	keys    []int
+	rows    [][]string
+	seen    map[string]bool
+	dropped int
+}
+
+func (sp *savantPitches) add(header []string, rows [][]string) error {
+	if sp.header == nil {
+		sp.header = header
+		sp.seen = make(map[string]bool)
+		sp.keys = make([]int, 0, 3)
+		for _, column := range []string{"game_pk", "at_bat_number", "pitch_number"} {
+			for i, name := range header {
+				if strings.TrimPrefix(name, "\ufeff") == column {
+					sp.keys = append(sp.keys, i)
+				}
+			}
+		}
+		if len(sp.keys) != 3 {
+			return fmt.Errorf("the Savant CSV header is missing game_pk, at_bat_number or pitch_number")
+		}
+	}
+
+	for _, row := range rows {
+		if len(row) != len(sp.header) {
+			return fmt.Errorf("the Savant CSV has %d columns but the header has %d", len(row), len(sp.header))
+		}
+		key := row[sp.keys[0]] + "|" + row[sp.keys[1]] + "|" + row[sp.keys[2]]
+		if sp.seen[key] {
+			sp.dropped++
+			continue
+		}
+		sp.seen[key] = true
+		sp.rows = append(sp.rows, row)
+	}
+	return nil
+}
+
+/*
+savantdownloadDate retrieves every pitch for a date and writes them to a single
+	CSV file.  The query for the whole day is tried first; if the response has
+	as many rows as the Statcast search will return, the query is split into
+	smaller chunks until none of them are truncated.  Nothing is written unless
+	every chunk was retrieved in full.  The file is compressed when the target ends
+	in .gz or .zst.
+*/
+func (gsg *GetSavantGames) savantdownloadDate(ctx context.Context, query SavantQuery, target string, downloads *manifest.Manifest, client util.Getter) error {
+	slog.Debug("Savant target", "path", target)
+	url := query.URL()
+	dt := query.Start
+
+	playerType := query.PlayerType
+	if len(playerType) == 0 {
+		playerType = "pitcher"
+	}
+	var pitches savantPitches
+	chunks := []savantChunk{{team: query.Team, playerType: playerType}}
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		header, rows, err := savantdownloadCSV(ctx, client, chunk.apply(query).URL())
+		if err != nil {
+			recordFailedDownload(downloads, url, target)
+			return err
+		}
+		if len(rows) >= gsg.rowCap {
+			smaller := chunk.split()
+			if len(smaller) == 0 {
+				recordFailedDownload(downloads, url, target)
+				return fmt.Errorf("the Savant query for %s (team %s, %s throwing to %s batters) still has %d rows and can't be split any further",
+					dt.Format("20060102"), chunk.team, chunk.throws, chunk.stands, len(rows))
+			}
+			slog.Info("Savant query may be truncated; splitting it",
+				"date", dt.Format("20060102"), "team", chunk.team, "rows", len(rows), "queries", len(smaller))
+			// The smaller queries cover every row of this one
+			chunks = append(chunks, smaller...)
+			continue
+		}
+		if header == nil {
+			continue
+		}
+		err = pitches.add(header, rows)
+		if err != nil {
+			recordFailedDownload(downloads, url, target)
+			return err
+		}
+	}
+	if pitches.dropped > 0 {
+		slog.Info("Removed duplicate pitches", "date", dt.Format("20060102"), "pitches", pitches.dropped)
+	}
+
+	size, err := util.WriteFileCompressed(target, func(f io.Writer) error {
+		w := csv.NewWriter(f)
+		if pitches.header != nil {
+			w.Write(pitches.header)
+		}
+		w.WriteAll(pitches.rows)
+		return w.Error()
+	})
+	if err != nil {
+		recordFailedDownload(downloads, url, target)
+		return err
+	}
+	results := summary.FromContext(ctx)
+	results.Add(summary.FilesDownloaded, 1)
+	results.Add(summary.BytesDownloaded, size)
+	err = downloads.RecordFile(url, target, 200)
+	if err != nil {
+		slog.Error("Unable to update the download manifest", "url", url, "err", err)
+	}
+	return nil
+}
+
+/*
+savantdownloadCSV retrieves a single Statcast search and returns its header and
+	rows.  An empty response (no pitches at all) has no header.
+*/
+func savantdownloadCSV(ctx context.Context, client util.Getter, url string) ([]string, [][]string, error) {
+	resp, err := util.GetContext(ctx, client, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if err = util.CheckResponse(resp); err != nil {
+		return nil, nil, err
+	}
+
+	r := csv.NewReader(resp.Body)
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
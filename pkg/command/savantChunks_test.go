@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/manifest"
+)
+
+func TestSavantChunking(t *testing.T) {
+	header := "\ufeffpitch_type,game_pk,at_bat_number,pitch_number\n"
+	// The whole day has six pitches.  NYY is too big on its own, and so are its
+	//	right-handed pitchers, so those are asked for by the side of the
+	//	batter.  BOS repeats a pitch from a game against NYY.
+	chunks := map[string]string{
+		"||":      "FF,1,1,1\nSL,1,1,2\nCH,1,2,1\n",
+		"NYY||":   "FF,1,1,1\nSL,1,1,2\nSL,1,1,3\n",
+		"NYY|R|":  "FF,1,1,1\nSL,1,1,2\nSL,1,1,3\n",
+		"NYY|R|R": "FF,1,1,1\n",
+		"NYY|R|L": "SL,1,1,2\nSL,1,1,3\n",
+		"NYY|L|":  "FF,2,1,1\n",
+		"BOS||":   "CH,1,2,1\nFF,1,1,1\n",
+		"HOU||":   "CU,3,5,4\n",
+	}
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		key := q.Get("team") + "|" + q.Get("pitcher_throws") + "|" + q.Get("batter_stands")
+		requests[key]++
+		if rows, ok := chunks[key]; ok {
+			fmt.Fprint(w, header+rows)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "savant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	downloads, _ := manifest.Load(dir)
+
+	gsg := &GetSavantGames{query: SavantQuery{BaseURL: server.URL}, rowCap: 3}
+	query := gsg.query.ForDate(time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC))
+	target := filepath.Join(dir, "20190610.csv")
+	err = gsg.savantdownloadDate(context.Background(), query, target, downloads, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requests["||"] != 1 || requests["LAA||"] != 1 || requests["NYY|R|L"] != 1 || requests["BOS|R|"] != 0 {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected a header and 6 pitches, got:\n%s", data)
+	}
+	if strings.Count(string(data), "FF,1,1,1") != 1 {
+		t.Errorf("duplicate pitch was not removed:\n%s", data)
+	}
+	if downloads.Complete(query.URL()) == false {
+		t.Errorf("merged file was not recorded in the manifest")
+	}
+
+	// A chunk that is still too big once it can't be split fails the date
+	chunks["NYY|R|L"] = "SL,1,1,2\nSL,1,1,3\nSL,1,1,4\n"
+	target = filepath.Join(dir, "20190611.csv")
+	query = gsg.query.ForDate(time.Date(2019, 6, 11, 0, 0, 0, 0, time.UTC))
+	err = gsg.savantdownloadDate(context.Background(), query, target, downloads, server.Client())
+	if err == nil {
+		t.Errorf("expected the truncated chunk to fail")
+	}
+	if _, err := os.Stat(target); err == nil {
+		t.Errorf("truncated date was written")
+	}
+	if downloads.Complete(query.URL()) {
+		t.Errorf("truncated date was recorded as complete")
+	}
+}
@@ -0,0 +1,192 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+savantGameTypes are the game types understood by the Statcast search:
+	regular season, wild card, division series, league championship series,
+	world series and spring training
+*/
+var savantGameTypes = map[string]bool{"R": true, "F": true, "D": true, "L": true, "W": true, "S": true}
+
+/*
+SavantQuery models the parameters of a Statcast search.  Anything that is left
+	empty is not used to filter the results, except for GameTypes and
+	PlayerType, which default to the regular season and pitchers.
+*/
+type SavantQuery struct {
+	BaseURL       string
+	GameTypes     []string
+	PlayerType    string
+	Team          string
+	PitchTypes    []string
+	Batters       []string
+	Pitchers      []string
+	PitcherThrows string
+	BatterStands  string
+	Start         time.Time
+	End           time.Time
+}
+
+/*
+URL encodes the query into the address of the CSV download.  The parameters are
+	kept in the order used by the Savant website, and a query with no filters
+	produces the same address the savant command has always used, so existing
+	manifests remain valid.
+*/
+func (sq SavantQuery) URL() string {
+	gameTypes := sq.GameTypes
+	if len(gameTypes) == 0 {
+		gameTypes = []string{"R"}
+	}
+	playerType := sq.PlayerType
+	if len(playerType) == 0 {
+		playerType = "pitcher"
+	}
+	end := sq.End
+	if end.IsZero() || end.Before(sq.Start) {
+		end = sq.Start
+	}
+	var seasons []string
+	for year := sq.Start.Year(); year <= end.Year(); year++ {
+		seasons = append(seasons, strconv.Itoa(year))
+	}
+
+	var query strings.Builder
+	param := func(name, value string) {
+		query.WriteString(name)
+		query.WriteString("=")
+		query.WriteString(value)
+		query.WriteString("&")
+	}
+	param("all", "true")
+	param("hfPT", savantList(sq.PitchTypes))
+	param("hfAB", "")
+	param("hfBBT", "")
+	param("hfPR", "")
+	param("hfZ", "")
+	param("stadium", "")
+	param("hfBBL", "")
+	param("hfNewZones", "")
+	param("hfGT", savantList(gameTypes))
+	param("hfC", "")
+	param("hfSea", savantList(seasons))
+	param("hfSit", "")
+	param("player_type", url.QueryEscape(playerType))
+	param("hfOuts", "")
+	param("opponent", "")
+	param("pitcher_throws", url.QueryEscape(sq.PitcherThrows))
+	param("batter_stands", url.QueryEscape(sq.BatterStands))
+	param("hfSA", "")
+	for _, batter := range sq.Batters {
+		param("batters_lookup%5B%5D", url.QueryEscape(batter))
+	}
+	for _, pitcher := range sq.Pitchers {
+		param("pitchers_lookup%5B%5D", url.QueryEscape(pitcher))
+	}
+	param("game_date_gt", sq.Start.Format("2006-01-02"))
+	param("game_date_lt", end.Format("2006-01-02"))
+	param("hfInfield", "")
+	param("team", url.QueryEscape(sq.Team))
+	param("position", "")
+	param("hfOutfield", "")
+	param("hfRO", "")
+	param("home_road", "")
+	param("hfFlag", "")
+	param("hfPull", "")
+	param("metric_1", "")
+	param("hfInn", "")
+	param("min_pitches", "0")
+	param("min_results", "0")
+	param("group_by", "name")
+	param("sort_col", "pitches")
+	param("player_event_sort", "h_launch_speed")
+	param("sort_order", "desc")
+	param("min_pas", "0")
+	param("type", "details")
+
+	return fmt.Sprintf("%s/statcast_search/csv?%s", sq.BaseURL, query.String())
+}
+
+/*
+ForDate returns a copy of the query limited to a single date
+*/
+func (sq SavantQuery) ForDate(date time.Time) SavantQuery {
+	sq.Start = date
+	sq.End = date
+	return sq
+}
+
+/*
+Validate checks the values that the Statcast search would otherwise silently
+	ignore
+*/
+func (sq SavantQuery) Validate() error {
+	for _, gameType := range sq.GameTypes {
+		if savantGameTypes[gameType] == false {
+			return fmt.Errorf("unknown game type %s (expected R, F, D, L, W or S)", gameType)
+		}
+	}
+	switch sq.PlayerType {
+	case "", "pitcher", "batter":
+	default:
+		return fmt.Errorf("unknown player type %s (expected pitcher or batter)", sq.PlayerType)
+	}
+	for _, players := range [][]string{sq.Batters, sq.Pitchers} {
+		for _, player := range players {
+			if _, err := strconv.Atoi(player); err != nil {
+				return fmt.Errorf("player %s is not an MLBAM id", player)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+savantList joins the values of a multiple-choice filter the way the Savant
+	website does, with a "|" after each value
+*/
+func savantList(values []string) string {
+	var list strings.Builder
+	for _, value := range values {
+		list.WriteString(url.QueryEscape(value))
+		list.WriteString("|")
+	}
+	return list.String()
+}
+
+/*
+splitList turns a comma separated flag value into its upper case parts
+*/
+func splitList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if len(part) > 0 {
+			values = append(values, part)
+		}
+	}
+	return values
+}
@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSavantQueryURL(t *testing.T) {
+	june10 := time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	// This is the address the savant command used before the query could be
+	//	changed; manifests written back then depend on it staying the same
+	original := "https://baseballsavant.mlb.com/statcast_search/csv?all=true&hfPT=&hfAB=&hfBBT=&hfPR=&hfZ=&stadium=&hfBBL=&hfNewZones=&hfGT=R|&hfC=&hfSea=2019|&hfSit=&player_type=pitcher&hfOuts=&opponent=&pitcher_throws=&batter_stands=&hfSA=&game_date_gt=2019-06-10&game_date_lt=2019-06-10&hfInfield=&team=&position=&hfOutfield=&hfRO=&home_road=&hfFlag=&hfPull=&metric_1=&hfInn=&min_pitches=0&min_results=0&group_by=name&sort_col=pitches&player_event_sort=h_launch_speed&sort_order=desc&min_pas=0&type=details&"
+	query := SavantQuery{BaseURL: "https://baseballsavant.mlb.com"}
+	if got := query.ForDate(june10).URL(); got != original {
+		t.Errorf("default query changed:\n%s\n%s", got, original)
+	}
+
+	tests := []struct {
+		name     string
+		query    SavantQuery
+		contains []string
+	}{
+		{
+			name:     "postseason",
+			query:    SavantQuery{GameTypes: []string{"F", "D", "L", "W"}},
+			contains: []string{"&hfGT=F|D|L|W|&"},
+		},
+		{
+			name:     "batter",
+			query:    SavantQuery{PlayerType: "batter", Team: "NYY", Batters: []string{"592450"}},
+			contains: []string{"&player_type=batter&", "&team=NYY&", "&batters_lookup%5B%5D=592450&"},
+		},
+		{
+			name:     "pitches",
+			query:    SavantQuery{PitchTypes: []string{"FF", "SL"}, Pitchers: []string{"477132", "605483"}},
+			contains: []string{"?all=true&hfPT=FF|SL|&", "&pitchers_lookup%5B%5D=477132&pitchers_lookup%5B%5D=605483&"},
+		},
+		{
+			name:     "seasons",
+			query:    SavantQuery{Start: time.Date(2018, 9, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)},
+			contains: []string{"&hfSea=2018|2019|&", "&game_date_gt=2018-09-01&game_date_lt=2019-04-01&"},
+		},
+	}
+	for _, test := range tests {
+		if test.query.Start.IsZero() {
+			test.query = test.query.ForDate(june10)
+		}
+		got := test.query.URL()
+		for _, part := range test.contains {
+			if strings.Contains(got, part) == false {
+				t.Errorf("%s: %s does not contain %s", test.name, got, part)
+			}
+		}
+	}
+}
+
+func TestSavantQueryValidate(t *testing.T) {
+	tests := []struct {
+		query SavantQuery
+		valid bool
+	}{
+		{SavantQuery{}, true},
+		{SavantQuery{GameTypes: splitList("r, s")}, true},
+		{SavantQuery{GameTypes: []string{"X"}}, false},
+		{SavantQuery{PlayerType: "fielder"}, false},
+		{SavantQuery{Batters: []string{"judge"}}, false},
+	}
+	for i, test := range tests {
+		if err := test.query.Validate(); (err == nil) != test.valid {
+			t.Errorf("test %d: unexpected result %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "serve-fixtures",
+		Synopsis:     "Run a fake gameday and Savant server for working offline",
+		New:          func() Command { return &ServeFixtures{} },
+		NoSummary:    true,
+		UntilStopped: true,
+	})
+}
+
+/*
+ServeFixtures contains information used to run a fake gameday and Savant
+	server, so that the other commands can be pointed at it with -url and
+	run without the network
+*/
+type ServeFixtures struct {
+	addr   string
+	dir    pathValue
+	faults string
+	delay  time.Duration
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (sf *ServeFixtures) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&sf.addr, "addr", "localhost:8080", "Address to listen on")
+	pathFlag(fs, &sf.dir, "dir", "", "Fixture tree to serve (default is the one built into the program)")
+	fs.StringVar(&sf.faults, "fault", "", "Comma separated faults to inject, each kind[:match[:count]] with a kind of 404, 500, slow or truncate")
+	fs.DurationVar(&sf.delay, "delay", 5*time.Second, "How long slow responses wait")
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (sf *ServeFixtures) Execute(ctx context.Context, args []string) error {
+	if sf.delay < 0 {
+		return usageErrorf("invalid delay %s", sf.delay)
+	}
+	faults, err := fixtures.ParseFaults(sf.faults)
+	if err != nil {
+		return &UsageError{Err: err}
+	}
+
+	files := fixtures.Default()
+	if dir := sf.dir.String(); len(dir) > 0 {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+		if info.IsDir() == false {
+			return usageErrorf("the fixture tree %s is not a directory", dir)
+		}
+		files = os.DirFS(dir)
+	}
+	return sf.serve(ctx, files, faults)
+}
+
+/*
+serve answers requests until the context is cancelled
+*/
+func (sf *ServeFixtures) serve(ctx context.Context, files fs.FS, faults []fixtures.Fault) error {
+	handler := fixtures.New(files, faults...)
+	handler.Delay = sf.delay
+
+	listener, err := net.Listen("tcp", sf.addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(listener)
+	}()
+	slog.Info("Serving fixtures", "url", "http://"+listener.Addr().String(), "faults", len(faults))
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = server.Shutdown(shutdown)
+	}
+	if err != nil && errors.Is(err, http.ErrServerClosed) == false {
+		return fmt.Errorf("the fixture server stopped: %w", err)
+	}
+	return nil
+}
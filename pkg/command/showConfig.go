@@ -0,0 +1,72 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bauer312/baseball/pkg/config"
+)
+
+func init() {
+	Register(Registration{
+		Name:      "config",
+		Synopsis:  "Print the effective settings and where each of them came from",
+		Args:      "show",
+		Complete:  func() []string { return []string{"show"} },
+		New:       func() Command { return &ShowConfig{} },
+		NoSummary: true,
+	})
+}
+
+/*
+ShowConfig prints the effective settings, in the format of the config file,
+	along with where each of them came from
+*/
+type ShowConfig struct {
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (sc *ShowConfig) SetFlags(fs *flag.FlagSet) {
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (sc *ShowConfig) Execute(ctx context.Context, args []string) error {
+	// show is the only thing the config command does so far
+	if len(args) != 1 || args[0] != "show" {
+		return usageErrorf("usage: baseball config show [-config file]")
+	}
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		return errors.New("no config has been loaded")
+	}
+	if len(cfg.Path) > 0 {
+		fmt.Printf("# config file: %s\n", cfg.Path)
+	} else {
+		fmt.Printf("# no config file; %s is used when it exists\n", config.DefaultPath())
+	}
+	cfg.Write(os.Stdout)
+	return nil
+}
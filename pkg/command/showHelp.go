@@ -0,0 +1,77 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"os"
+)
+
+func init() {
+	Register(Registration{
+		Name:      "help",
+		Synopsis:  "Show the commands, or the flags of a single command",
+		Args:      "[command]",
+		Complete:  commandNames,
+		New:       func() Command { return &ShowHelp{} },
+		NoSummary: true,
+	})
+}
+
+/*
+ShowHelp prints the list of commands, or the help of a single command
+*/
+type ShowHelp struct {
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (sh *ShowHelp) SetFlags(fs *flag.FlagSet) {
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (sh *ShowHelp) Execute(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		PrintCommands(os.Stdout)
+		return nil
+	}
+	if len(args) > 1 {
+		return usageErrorf("help takes a single command")
+	}
+	r, ok := Lookup(args[0])
+	if ok == false {
+		return usageErrorf("unknown command %s", args[0])
+	}
+	fs, _ := NewFlagSet(r, &Globals{})
+	PrintUsage(os.Stdout, r, fs)
+	return nil
+}
+
+/*
+commandNames lists the names of the registered commands
+*/
+func commandNames() []string {
+	var names []string
+	for _, r := range Registered() {
+		names = append(names, r.Name)
+	}
+	return names
+}
@@ -0,0 +1,450 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/config"
+	"github.com/bauer312/baseball/pkg/db"
+	"github.com/bauer312/baseball/pkg/manifest"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func init() {
+	Register(Registration{
+		Name:     "sync",
+		Synopsis: "Download and load the dates missing from mlb_savant and mlb_gameday",
+		New:      func() Command { return &SyncDates{} },
+	})
+}
+
+/*
+SyncDates contains information used to catch up on the dates that are
+	missing from the database, or that are missing some of their games.
+	The dates are downloaded and loaded with the same logic as the savant,
+	gameday, loadsavant and loadgameday commands, so running it again once
+	it has succeeded does nothing.
+*/
+type SyncDates struct {
+	start      dateValue
+	end        dateValue
+	output     pathValue
+	dsn        string
+	feedList   string
+	feeds      []string
+	savantURL  string
+	gamedayURL string
+	source     string
+	gameTypes  string
+	compress   compressValue
+	fetch      fetchOptions
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (sd *SyncDates) SetFlags(fs *flag.FlagSet) {
+	dateFlag(fs, &sd.start, "start", "", "First `date` to check (YYYYMMDD, default is the start of the season that has been loaded)")
+	dateFlag(fs, &sd.end, "end", "yesterday", "Last `date` to check")
+	pathFlag(fs, &sd.output, "output", "", "Output `directory` for downloaded files")
+	fs.StringVar(&sd.dsn, "db", "", "Database connection string (default from the config file)")
+	fs.StringVar(&sd.feedList, "feeds", "savant,gameday", "Comma separated data to catch up on: savant, gameday")
+	fs.StringVar(&sd.savantURL, "savant-url", "https://baseballsavant.mlb.com", "Source location of Savant data")
+	fs.StringVar(&sd.gamedayURL, "gameday-url", "http://gd2.mlb.com", "Source location of Gameday data")
+	fs.StringVar(&sd.source, "source", "http", util.SourceHelp)
+	fs.StringVar(&sd.gameTypes, "game-types", "R", "Comma separated Savant game types: R (regular season), F, D, L, W (postseason rounds) and S (spring training)")
+	compressFlag(fs, &sd.compress, "compress", util.CompressNone, util.CompressHelp)
+	sd.fetch.setFlags(fs)
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (sd *SyncDates) Execute(ctx context.Context, args []string) error {
+	var err error
+	if sd.feeds, err = parseFeeds(sd.feedList); err != nil {
+		return &UsageError{Err: err}
+	}
+	if len(sd.output) == 0 {
+		sd.output = pathValue(config.DefaultDataRoot())
+	}
+	end, err := singleDate(sd.end.String())
+	if err != nil {
+		return usageErrorf("invalid end date: %w", err)
+	}
+	var start time.Time
+	if len(sd.start) > 0 {
+		start, err = singleDate(sd.start.String())
+		if err != nil {
+			return usageErrorf("invalid start date: %w", err)
+		}
+	}
+
+	missing, partial, err := sd.findMissing(ctx, start, end)
+	if err != nil {
+		return fmt.Errorf("unable to work out the missing dates: %w", err)
+	}
+
+	for _, feed := range sd.feeds {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if len(missing[feed]) == 0 {
+			slog.Info("Nothing is missing", "feed", feed, "end", end.Format("20060102"))
+			continue
+		}
+		slog.Info("Catching up", "feed", feed, "dates", len(missing[feed]),
+			"first", missing[feed][0].Format("20060102"), "last", missing[feed][len(missing[feed])-1].Format("20060102"))
+		switch feed {
+		case "savant":
+			err = sd.syncSavant(ctx, missing[feed], partial[feed])
+		case "gameday":
+			err = sd.syncGameday(ctx, missing[feed])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+findMissing looks at what has been loaded into each table, and at the games
+	the pipeline has put in the GameRecord table, to decide which dates each
+	feed is missing.  A date that has some of its games loaded but not all
+	of them is missing as well, and is also returned as partial.
+*/
+func (sd *SyncDates) findMissing(ctx context.Context, start, end time.Time) (map[string][]time.Time, map[string]map[string]bool, error) {
+	bbdb := db.BaseballDB{}
+	err := bbdb.Connect(sd.dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer bbdb.Close()
+
+	schedule, err := bbdb.ScheduledGames(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheduled := make(map[string]bool)
+	for key := range schedule {
+		scheduled[key] = true
+	}
+
+	missing := make(map[string][]time.Time)
+	partial := make(map[string]map[string]bool)
+	for _, feed := range sd.feeds {
+		table := "mlb_savant"
+		confirm := bbdb.ConfirmSavantMaster
+		if feed == "gameday" {
+			table = "mlb_gameday"
+			confirm = bbdb.ConfirmGamedayMaster
+		}
+		if err = confirm(ctx); err != nil {
+			return nil, nil, err
+		}
+		games, err := bbdb.LoadedGames(ctx, table)
+		if err != nil {
+			return nil, nil, err
+		}
+		loaded := make(map[string]bool)
+		for key := range games {
+			loaded[key] = true
+		}
+		if feed == "gameday" {
+			partial[feed] = sd.partialGameday(games, schedule)
+		} else {
+			partial[feed] = sd.partialSavant(games, schedule)
+		}
+		missing[feed], err = missingDates(loaded, partial[feed], scheduled, start, end)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return missing, partial, nil
+}
+
+/*
+partialSavant returns the dates in mlb_savant that are missing a game that
+	the schedule shows was played, of one of the game types being synced
+*/
+func (sd *SyncDates) partialSavant(loaded map[string]map[string]bool, schedule map[string][]db.ScheduledGame) map[string]bool {
+	gameTypes := make(map[string]bool)
+	for _, gameType := range splitList(sd.gameTypes) {
+		gameTypes[gameType] = true
+	}
+	partial := make(map[string]bool)
+	for key, games := range loaded {
+		for _, game := range schedule[key] {
+			if game.Played() && gameTypes[game.GameType] && games[strconv.FormatInt(game.ID, 10)] == false {
+				partial[key] = true
+			}
+		}
+	}
+	return partial
+}
+
+/*
+partialGameday returns the dates in mlb_gameday that are missing a game.  A
+	game is missing when the manifest shows that its inning_all file could
+	not be downloaded, or was downloaded but isn't in the table because it
+	failed to load.  A date with fewer games than the schedule shows were
+	played is missing the others.
+*/
+func (sd *SyncDates) partialGameday(loaded map[string]map[string]bool, schedule map[string][]db.ScheduledGame) map[string]bool {
+	partial := make(map[string]bool)
+	for key, games := range loaded {
+		played := 0
+		for _, game := range schedule[key] {
+			if game.Played() {
+				played++
+			}
+		}
+		if len(games) < played {
+			partial[key] = true
+		}
+	}
+
+	dir := filepath.Join(sd.output.String(), "gameday", "raw")
+	downloads, err := manifest.Load(dir)
+	if err != nil {
+		slog.Warn("Unable to read the download manifest", "dir", dir, "err", err)
+	}
+	for _, entry := range downloads.Entries() {
+		key, game, ok := gamedayGame(entry.URL)
+		if ok == false || len(loaded[key]) == 0 {
+			continue
+		}
+		// A game that wasn't played has no inning_all file
+		if entry.Status == http.StatusNotFound {
+			continue
+		}
+		if entry.Succeeded() == false || loaded[key][game] == false {
+			partial[key] = true
+		}
+	}
+	return partial
+}
+
+/*
+gamedayGame returns the date (YYYYMMDD) of the inning_all file at a URL, and
+	the game named as in LoadedGames, such as nya_bos_1 for
+	.../gid_2019_06_10_nyamlb_bosmlb_1/inning/inning_all.xml
+*/
+func gamedayGame(url string) (string, string, bool) {
+	if strings.HasSuffix(url, "/inning/inning_all.xml") == false {
+		return "", "", false
+	}
+	i := strings.Index(url, "gid_")
+	if i < 0 {
+		return "", "", false
+	}
+	fields := strings.Split(strings.TrimSuffix(url[i:], "/inning/inning_all.xml"), "_")
+	if len(fields) != 7 || len(fields[4]) < 3 || len(fields[5]) < 3 {
+		return "", "", false
+	}
+	date := fields[1] + fields[2] + fields[3]
+	if _, err := time.Parse("20060102", date); err != nil {
+		return "", "", false
+	}
+	return date, fields[4][:3] + "_" + fields[5][:3] + "_" + strings.TrimLeft(fields[6], "0"), true
+}
+
+/*
+missingDates lists the dates up to end that haven't been loaded, or that have
+	only been loaded in part.  Without a start, the check begins at the first
+	date loaded in the season of the end date, or the day after the last
+	date loaded in an earlier season.  Dates that the schedule covers but
+	that had no games are not missing.
+*/
+func missingDates(loaded, partial, scheduled map[string]bool, start, end time.Time) ([]time.Time, error) {
+	endKey := end.Format("20060102")
+	season := end.Format("2006")
+	if start.IsZero() {
+		first := ""
+		for key := range loaded {
+			if strings.HasPrefix(key, season) && key <= endKey && (first == "" || key < first) {
+				first = key
+			}
+		}
+		if first == "" {
+			if last := lastKey(loaded, endKey); last != "" {
+				lastLoaded, _ := time.ParseInLocation("20060102", last, time.Local)
+				first = lastLoaded.AddDate(0, 0, 1).Format("20060102")
+			}
+		}
+		if first == "" {
+			for key := range scheduled {
+				if strings.HasPrefix(key, season) && key <= endKey && (first == "" || key < first) {
+					first = key
+				}
+			}
+		}
+		if first == "" {
+			return nil, fmt.Errorf("nothing has been loaded yet, so a start date is needed")
+		}
+		start, _ = time.ParseInLocation("20060102", first, time.Local)
+	}
+
+	var firstScheduled string
+	for key := range scheduled {
+		if firstScheduled == "" || key < firstScheduled {
+			firstScheduled = key
+		}
+	}
+	lastScheduled := lastKey(scheduled, "99999999")
+
+	var missing []time.Time
+	for dt := start; dt.After(end) == false; dt = dt.AddDate(0, 0, 1) {
+		key := dt.Format("20060102")
+		if loaded[key] && partial[key] == false {
+			continue
+		}
+		if key >= firstScheduled && key <= lastScheduled && scheduled[key] == false {
+			continue
+		}
+		missing = append(missing, dt)
+	}
+	return missing, nil
+}
+
+func lastKey(dates map[string]bool, limit string) string {
+	last := ""
+	for key := range dates {
+		if key <= limit && key > last {
+			last = key
+		}
+	}
+	return last
+}
+
+/*
+syncSavant downloads the missing dates and loads the files for them.  Savant
+	didn't have every game of a partial date when it was downloaded, so the
+	date is downloaded again and its pitches are replaced.
+*/
+func (sd *SyncDates) syncSavant(ctx context.Context, dates []time.Time, partial map[string]bool) error {
+	gsg := &GetSavantGames{
+		output:   sd.output,
+		compress: sd.compress,
+		fetch:    sd.fetch,
+		rowCap:   savantRowCap,
+		query: SavantQuery{
+			BaseURL:    sd.savantURL,
+			GameTypes:  splitList(sd.gameTypes),
+			PlayerType: "pitcher",
+		},
+	}
+	if err := gsg.query.Validate(); err != nil {
+		return &UsageError{Err: err}
+	}
+	var fresh, again []time.Time
+	for _, dt := range dates {
+		if partial[dt.Format("20060102")] {
+			again = append(again, dt)
+		} else {
+			fresh = append(fresh, dt)
+		}
+	}
+	if err := gsg.downloadDates(ctx, fresh); err != nil || ctx.Err() != nil {
+		return err
+	}
+	gsg.force = true
+	if err := gsg.downloadDates(ctx, again); err != nil || ctx.Err() != nil {
+		return err
+	}
+	if err := deleteSavantDates(ctx, sd.dsn, again); err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, dt := range dates {
+		path := filepath.Join(sd.output.String(), "savant", dt.Format("20060102")+".csv")
+		if stored, ok := util.FindFile(path); ok {
+			paths = append(paths, stored)
+		}
+	}
+	return loadSavantFiles(ctx, sd.dsn, paths)
+}
+
+/*
+deleteSavantDates removes the pitches of the dates that are about to be
+	loaded again
+*/
+func deleteSavantDates(ctx context.Context, dsn string, dates []time.Time) error {
+	if len(dates) == 0 {
+		return nil
+	}
+	bbdb := db.BaseballDB{}
+	err := bbdb.Connect(dsn)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the database: %w", err)
+	}
+	defer bbdb.Close()
+	for _, dt := range dates {
+		err = bbdb.DeleteSavantDate(ctx, dt)
+		if err != nil {
+			return fmt.Errorf("unable to replace the pitches of %s: %w", dt.Format("20060102"), err)
+		}
+	}
+	return nil
+}
+
+/*
+syncGameday downloads the inning_all files of the missing dates and loads
+	them
+*/
+func (sd *SyncDates) syncGameday(ctx context.Context, dates []time.Time) error {
+	ggg := &GetGamedayGames{
+		output:   sd.output,
+		url:      sd.gamedayURL,
+		source:   sd.source,
+		files:    util.DefaultGameFiles,
+		compress: sd.compress,
+		fetch:    sd.fetch,
+	}
+	if err := ggg.downloadDates(ctx, dates); err != nil || ctx.Err() != nil {
+		return err
+	}
+
+	var paths []string
+	for _, dt := range dates {
+		pattern := filepath.Join(sd.output.String(), "gameday", "raw", dt.Format("gid_2006_01_02_")+"*_inning_all.xml*")
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			slog.Error("Unable to find the downloaded files", "pattern", pattern, "err", err)
+			continue
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if strings.HasSuffix(util.TrimCompression(match), "_inning_all.xml") {
+				paths = append(paths, match)
+			}
+		}
+	}
+	return loadGamedayFiles(ctx, sd.dsn, paths)
+}
@@ -0,0 +1,152 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/db"
+	"github.com/bauer312/baseball/pkg/manifest"
+)
+
+func TestMissingDates(t *testing.T) {
+	dates := func(keys ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, key := range keys {
+			m[key] = true
+		}
+		return m
+	}
+	day := func(key string) time.Time {
+		dt, _ := time.ParseInLocation("20060102", key, time.Local)
+		return dt
+	}
+
+	var missingTest = []struct {
+		Name      string
+		Loaded    map[string]bool
+		Partial   map[string]bool
+		Scheduled map[string]bool
+		Start     string
+		End       string
+		Expected  string
+	}{
+		// A gap within the season and the days since the last load
+		{"gaps", dates("20190601", "20190602", "20190604"), nil, nil, "", "20190606", "20190603,20190605,20190606"},
+		// An off day in the schedule is not missing
+		{"off day", dates("20190601", "20190602", "20190604"), nil, dates("20190601", "20190602", "20190604", "20190605"), "", "20190606", "20190605,20190606"},
+		// Nothing loaded this season yet, so pick up after the last load
+		{"new season", dates("20181029", "20181030"), nil, nil, "", "20181102", "20181031,20181101,20181102"},
+		// The schedule says where the season starts when nothing is loaded
+		{"schedule", nil, nil, dates("20190328", "20190329"), "", "20190330", "20190328,20190329,20190330"},
+		{"start", dates("20190601"), nil, nil, "20190530", "20190601", "20190530,20190531"},
+		{"up to date", dates("20190601", "20190602"), nil, nil, "", "20190602", ""},
+		// A date that is missing some of its games is caught up again
+		{"partial", dates("20190601", "20190602", "20190603"), dates("20190602"), nil, "", "20190603", "20190602"},
+	}
+
+	for _, tt := range missingTest {
+		var start time.Time
+		if len(tt.Start) > 0 {
+			start = day(tt.Start)
+		}
+		missing, err := missingDates(tt.Loaded, tt.Partial, tt.Scheduled, start, day(tt.End))
+		if err != nil {
+			t.Errorf("%s: %s", tt.Name, err)
+			continue
+		}
+		var keys []string
+		for _, dt := range missing {
+			keys = append(keys, dt.Format("20060102"))
+		}
+		if strings.Join(keys, ",") != tt.Expected {
+			t.Errorf("%s: expected %s, got %s", tt.Name, tt.Expected, strings.Join(keys, ","))
+		}
+	}
+
+	if _, err := missingDates(nil, nil, nil, time.Time{}, day("20190601")); err == nil {
+		t.Errorf("Expected an error when there is nowhere to start")
+	}
+}
+
+func TestPartialDates(t *testing.T) {
+	games := func(keys ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, key := range keys {
+			m[key] = true
+		}
+		return m
+	}
+	schedule := map[string][]db.ScheduledGame{
+		"20190610": {{ID: 565000, GameType: "R", Status: "Final"}, {ID: 565001, GameType: "R", Status: "Final"}},
+		"20190611": {{ID: 565012, GameType: "R", Status: "Final"}, {ID: 565013, GameType: "R", Status: "Postponed"}},
+		"20190612": {{ID: 565024, GameType: "R", Status: "Final"}},
+		"20190613": {{ID: 565036, GameType: "R", Status: "Final"}},
+	}
+
+	// Savant is missing a game on the 10th; the postponed game on the 11th
+	//	was never played
+	sd := &SyncDates{gameTypes: "R", output: pathValue(t.TempDir())}
+	savant := map[string]map[string]bool{
+		"20190610": games("565000"),
+		"20190611": games("565012"),
+	}
+	if partial := sd.partialSavant(savant, schedule); len(partial) != 1 || partial["20190610"] == false {
+		t.Errorf("Expected the 10th to be partial in mlb_savant, got %v", partial)
+	}
+
+	// Gameday has both games of the 10th.  The file of the 12th didn't load
+	//	and the file of the 13th didn't download.
+	dir := filepath.Join(sd.output.String(), "gameday", "raw")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	downloads, _ := manifest.Load(dir)
+	day := "http://gd2.mlb.com/components/game/mlb/year_2019/month_06/"
+	for _, entry := range []manifest.Entry{
+		{URL: day + "day_10/gid_2019_06_10_nyamlb_bosmlb_1/inning/inning_all.xml", Status: 200},
+		{URL: day + "day_10/gid_2019_06_10_nyamlb_bosmlb_2/inning/inning_all.xml", Status: 200},
+		{URL: day + "day_11/gid_2019_06_11_nyamlb_bosmlb_1/inning/inning_all.xml", Status: 200},
+		{URL: day + "day_11/gid_2019_06_11_nyamlb_bosmlb_2/inning/inning_all.xml", Status: 404},
+		{URL: day + "day_12/gid_2019_06_12_nyamlb_bosmlb_1/inning/inning_all.xml", Status: 200},
+		{URL: day + "day_13/gid_2019_06_13_houmlb_bosmlb_1/inning/inning_all.xml"},
+		{URL: day + "day_13/gid_2019_06_13_nyamlb_bosmlb_1/game.xml"},
+	} {
+		if err := downloads.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gameday := map[string]map[string]bool{
+		"20190610": games("nya_bos_1", "nya_bos_2"),
+		"20190611": games("nya_bos_1"),
+		"20190612": games("hou_bos_1"),
+		"20190613": games("nya_bos_1"),
+	}
+	partial := sd.partialGameday(gameday, schedule)
+	if len(partial) != 2 || partial["20190612"] == false || partial["20190613"] == false {
+		t.Errorf("Expected the 12th and 13th to be partial in mlb_gameday, got %v", partial)
+	}
+	// Fewer games than were played
+	gameday["20190610"] = games("nya_bos_1")
+	if partial := sd.partialGameday(gameday, schedule); partial["20190610"] == false {
+		t.Errorf("Expected the 10th to be partial in mlb_gameday, got %v", partial)
+	}
+}
@@ -0,0 +1,444 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/config"
+	"github.com/bauer312/baseball/pkg/manifest"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+quarantineDir is the directory, inside the savant or gameday directory, that
+	bad files are moved to
+*/
+const quarantineDir = "quarantine"
+
+func init() {
+	Register(Registration{
+		Name:     "verify",
+		Synopsis: "Check the downloaded files, optionally setting aside and downloading again the bad ones",
+		New:      func() Command { return &VerifyArchive{} },
+	})
+}
+
+/*
+VerifyArchive contains information used to check the files that have been
+	downloaded.  Every problem is reported as a failure in the summary;
+	the bad files can also be moved out of the way and downloaded again.
+*/
+type VerifyArchive struct {
+	output     pathValue
+	start      dateValue
+	end        dateValue
+	feedList   string
+	feeds      []string
+	quarantine bool
+	refetch    bool
+	savantURL  string
+	gamedayURL string
+	source     string
+	gameTypes  string
+	compress   compressValue
+	fetch      fetchOptions
+}
+
+/*
+archiveFeed is one of the directories being verified and what was found in it
+*/
+type archiveFeed struct {
+	name      string
+	dir       string
+	downloads *manifest.Manifest
+	bad       []string
+	dates     map[string]bool
+	missing   []time.Time
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (va *VerifyArchive) SetFlags(fs *flag.FlagSet) {
+	pathFlag(fs, &va.output, "output", "", "Location of the downloaded files")
+	dateFlag(fs, &va.start, "start", "", "First `date` that should have files (YYYYMMDD, default is the first file of each season)")
+	dateFlag(fs, &va.end, "end", "", "Last `date` that should have files (YYYYMMDD, default is the last file of each season)")
+	fs.StringVar(&va.feedList, "feeds", "savant,gameday", "Comma separated data to verify: savant, gameday")
+	fs.BoolVar(&va.quarantine, "quarantine", false, "Move bad files into a quarantine directory")
+	fs.BoolVar(&va.refetch, "refetch", false, "Download bad files and dates with no files again")
+	fs.StringVar(&va.savantURL, "savant-url", "https://baseballsavant.mlb.com", "Source location of Savant data")
+	fs.StringVar(&va.gamedayURL, "gameday-url", "http://gd2.mlb.com", "Source location of Gameday data")
+	fs.StringVar(&va.source, "source", "http", util.SourceHelp)
+	fs.StringVar(&va.gameTypes, "game-types", "R", "Comma separated Savant game types to download again")
+	compressFlag(fs, &va.compress, "compress", util.CompressNone, util.CompressHelp)
+	va.fetch.setFlags(fs)
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (va *VerifyArchive) Execute(ctx context.Context, args []string) error {
+	var err error
+	if va.feeds, err = parseFeeds(va.feedList); err != nil {
+		return &UsageError{Err: err}
+	}
+	if len(va.output) == 0 {
+		va.output = pathValue(config.DefaultDataRoot())
+	}
+
+	var start, end time.Time
+	if len(va.start) > 0 {
+		if start, err = singleDate(va.start.String()); err != nil {
+			return usageErrorf("invalid start date: %w", err)
+		}
+	}
+	if len(va.end) > 0 {
+		if end, err = singleDate(va.end.String()); err != nil {
+			return usageErrorf("invalid end date: %w", err)
+		}
+	}
+
+	for _, name := range va.feeds {
+		if ctx.Err() != nil {
+			return nil
+		}
+		feed := &archiveFeed{name: name, dir: filepath.Join(va.output.String(), "savant")}
+		if name == "gameday" {
+			feed.dir = filepath.Join(va.output.String(), "gameday", "raw")
+		}
+		feed.downloads, err = manifest.Load(feed.dir)
+		if err != nil {
+			slog.Warn("Unable to read the whole download manifest", "dir", feed.dir, "err", err)
+		}
+
+		va.checkFiles(ctx, feed)
+		va.findMissing(ctx, feed, start, end)
+		if va.quarantine {
+			va.quarantineFiles(feed)
+		}
+		if va.refetch {
+			if err = va.refetchFiles(ctx, feed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+checkFiles checks every file in the directory, including that no two files
+	have the same content
+*/
+func (va *VerifyArchive) checkFiles(ctx context.Context, feed *archiveFeed) {
+	results := summary.FromContext(ctx)
+	feed.dates = make(map[string]bool)
+	entries, err := os.ReadDir(feed.dir)
+	if os.IsNotExist(err) {
+		slog.Info("Nothing has been downloaded", "dir", feed.dir)
+		return
+	}
+	if err != nil {
+		slog.Error("Unable to read the directory", "dir", feed.dir, "err", err)
+		results.Fail(feed.dir, err)
+		return
+	}
+
+	slog.Info("Verifying files", "dir", feed.dir, "files", len(entries))
+	contents := make(map[string]string)
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		date, ok := archiveFileDate(feed.name, entry.Name())
+		if entry.IsDir() || ok == false {
+			continue
+		}
+		feed.dates[date] = true
+		path := filepath.Join(feed.dir, entry.Name())
+		results.Request(path)
+		err := feed.checkFile(path)
+		if err == nil {
+			size, sum, hashErr := manifest.HashFile(path)
+			if hashErr == nil && size > 0 {
+				if other, ok := contents[sum]; ok {
+					err = fmt.Errorf("has the same content as %s", filepath.Base(other))
+				}
+				contents[sum] = path
+			}
+		}
+		if err != nil {
+			feed.bad = append(feed.bad, path)
+			slog.Warn("Bad file", "path", path, "problem", err)
+			results.Fail(path, err)
+		} else {
+			results.Done(path)
+		}
+	}
+}
+
+/*
+checkFile runs the checks for the kind of file
+*/
+func (feed *archiveFeed) checkFile(path string) error {
+	if feed.name == "gameday" {
+		return checkGamedayFile(path)
+	}
+	rows, err := checkSavantFile(path)
+	if err == nil && rows == 0 {
+		slog.Debug("No pitches in the file", "path", path)
+	}
+	return err
+}
+
+/*
+findMissing looks for dates with no files between the first and last date of
+	each season that has files, or between the start and end that were asked
+	for.  Savant has a file for every date that was downloaded, so a date
+	without one is a problem.  Gameday only has files for the games that
+	were played, so a date without any is probably an off day.
+*/
+func (va *VerifyArchive) findMissing(ctx context.Context, feed *archiveFeed, start, end time.Time) {
+	results := summary.FromContext(ctx)
+	seasons := make(map[string][2]string)
+	for date := range feed.dates {
+		season := seasons[date[:4]]
+		if len(season[0]) == 0 || date < season[0] {
+			season[0] = date
+		}
+		if date > season[1] {
+			season[1] = date
+		}
+		seasons[date[:4]] = season
+	}
+	if start.IsZero() == false && end.IsZero() == false {
+		seasons = map[string][2]string{"": {start.Format("20060102"), end.Format("20060102")}}
+	}
+
+	var noFiles []string
+	for _, season := range seasons {
+		first, _ := time.ParseInLocation("20060102", season[0], time.Local)
+		last, _ := time.ParseInLocation("20060102", season[1], time.Local)
+		if start.IsZero() == false && first.Before(start) {
+			first = start
+		}
+		if end.IsZero() == false && last.After(end) {
+			last = end
+		}
+		for dt := first; dt.After(last) == false; dt = dt.AddDate(0, 0, 1) {
+			if feed.dates[dt.Format("20060102")] == false {
+				feed.missing = append(feed.missing, dt)
+				noFiles = append(noFiles, dt.Format("20060102"))
+			}
+		}
+	}
+	sort.Slice(feed.missing, func(i, j int) bool { return feed.missing[i].Before(feed.missing[j]) })
+	sort.Strings(noFiles)
+
+	for _, date := range noFiles {
+		if feed.name == "savant" {
+			slog.Warn("No file for the date", "feed", feed.name, "date", date)
+			results.Fail(feed.name+" "+date, errors.New("no file"))
+		} else {
+			slog.Info("No files for the date, which may be an off day", "feed", feed.name, "date", date)
+		}
+	}
+}
+
+/*
+quarantineFiles moves the bad files out of the directory and marks them as
+	incomplete in the manifest, so that the next download gets them again
+*/
+func (va *VerifyArchive) quarantineFiles(feed *archiveFeed) {
+	if len(feed.bad) == 0 {
+		return
+	}
+	dir := filepath.Join(feed.dir, quarantineDir)
+	if err := os.MkdirAll(dir, 0740); err != nil {
+		slog.Error("Unable to create the quarantine directory", "dir", dir, "err", err)
+		return
+	}
+	urls := feed.urls()
+	for _, path := range feed.bad {
+		target := filepath.Join(dir, filepath.Base(path))
+		if err := os.Rename(path, target); err != nil {
+			slog.Error("Unable to quarantine file", "path", path, "err", err)
+			continue
+		}
+		slog.Info("Quarantined file", "path", path, "to", target)
+		if url, ok := urls[path]; ok {
+			recordFailedDownload(feed.downloads, url, path)
+		}
+	}
+}
+
+/*
+refetchFiles downloads the bad files and the dates with no files again, then
+	checks what was downloaded
+*/
+func (va *VerifyArchive) refetchFiles(ctx context.Context, feed *archiveFeed) error {
+	if len(feed.bad) == 0 && len(feed.missing) == 0 {
+		return nil
+	}
+	results := summary.FromContext(ctx)
+	if feed.name == "savant" {
+		dates := feed.missing
+		for _, path := range feed.bad {
+			date, _ := archiveFileDate(feed.name, filepath.Base(path))
+			if dt, err := time.ParseInLocation("20060102", date, time.Local); err == nil {
+				dates = append(dates, dt)
+			}
+		}
+		gsg := &GetSavantGames{
+			output:   va.output,
+			force:    true,
+			compress: va.compress,
+			fetch:    va.fetch,
+			rowCap:   savantRowCap,
+			query: SavantQuery{
+				BaseURL:    va.savantURL,
+				GameTypes:  splitList(va.gameTypes),
+				PlayerType: "pitcher",
+			},
+		}
+		if err := gsg.query.Validate(); err != nil {
+			return &UsageError{Err: err}
+		}
+		if err := gsg.downloadDates(ctx, dates); err != nil {
+			return err
+		}
+		for _, dt := range feed.missing {
+			path, ok := util.FindFile(filepath.Join(feed.dir, dt.Format("20060102")+".csv"))
+			if ok && feed.checkFile(path) == nil {
+				results.Done(feed.name + " " + dt.Format("20060102"))
+			}
+		}
+	} else {
+		ggg := &GetGamedayGames{
+			output:   va.output,
+			url:      va.gamedayURL,
+			source:   va.source,
+			files:    util.DefaultGameFiles,
+			compress: va.compress,
+			fetch:    va.fetch,
+		}
+		if len(feed.missing) > 0 {
+			if err := ggg.downloadDates(ctx, feed.missing); err != nil {
+				return err
+			}
+		}
+		if err := va.refetchGameday(ctx, feed); err != nil {
+			return err
+		}
+	}
+
+	// A file that was downloaded again replaces its failure in the summary,
+	//	even when it is now stored in another format
+	for _, path := range feed.bad {
+		stored, ok := util.FindFile(util.TrimCompression(path))
+		if ok == false {
+			continue
+		}
+		if err := feed.checkFile(stored); err != nil {
+			slog.Warn("File is still bad after downloading it again", "path", path, "problem", err)
+		} else {
+			results.Done(path)
+		}
+	}
+	return nil
+}
+
+/*
+refetchGameday downloads the bad gameday files again from the URLs in the
+	manifest
+*/
+func (va *VerifyArchive) refetchGameday(ctx context.Context, feed *archiveFeed) error {
+	urls := feed.urls()
+	var refetch []string
+	for _, path := range feed.bad {
+		if url, ok := urls[path]; ok {
+			refetch = append(refetch, url)
+		} else {
+			slog.Warn("The manifest doesn't say where the file came from", "path", path)
+		}
+	}
+	if len(refetch) == 0 {
+		return nil
+	}
+	client, err := va.fetch.newFetcher(va.source)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	paths := make(chan string)
+	go printFilePath(ctx, &wg, paths, va.output.String(), true, va.compress.String(), client)
+	for _, url := range refetch {
+		paths <- url
+	}
+	close(paths)
+	wg.Wait()
+	return nil
+}
+
+/*
+urls maps the files in the directory to the URLs they were downloaded from
+*/
+func (feed *archiveFeed) urls() map[string]string {
+	urls := make(map[string]string)
+	for _, entry := range feed.downloads.Entries() {
+		urls[entry.Path] = entry.URL
+	}
+	return urls
+}
+
+/*
+archiveFileDate returns the date (YYYYMMDD) of a downloaded file from its
+	name: YYYYMMDD.csv for Savant and gid_YYYY_MM_DD_... for Gameday, either
+	of them possibly compressed
+*/
+func archiveFileDate(feed, name string) (string, bool) {
+	var date string
+	name = util.TrimCompression(name)
+	if feed == "savant" {
+		if strings.HasSuffix(name, ".csv") == false {
+			return "", false
+		}
+		date = strings.TrimSuffix(name, ".csv")
+	} else {
+		if strings.HasPrefix(name, "gid_") == false || len(name) < 14 {
+			return "", false
+		}
+		date = strings.ReplaceAll(name[4:14], "_", "")
+	}
+	if _, err := time.Parse("20060102", date); err != nil {
+		return "", false
+	}
+	return date, true
+}
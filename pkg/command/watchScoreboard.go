@@ -0,0 +1,285 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/catalog"
+	"github.com/bauer312/baseball/pkg/pipelinestage"
+	"github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+finishedStatuses are the game statuses after which nothing more will change
+*/
+var finishedStatuses = map[string]bool{
+	"Final":           true,
+	"Game Over":       true,
+	"Completed Early": true,
+	"Postponed":       true,
+	"Cancelled":       true,
+	"Suspended":       true,
+	"Forfeit":         true,
+}
+
+func init() {
+	Register(Registration{
+		Name:     "watch",
+		Synopsis: "Poll the scoreboard during the games, sending only what changed, until every game is over",
+		New:      func() Command { return &WatchScoreboard{} },
+	})
+}
+
+/*
+WatchScoreboard contains information used to follow a day of games as they
+	are played.  The scoreboard is read over and over, and only the records
+	that changed since the last read are sent to the sink.
+*/
+type WatchScoreboard struct {
+	date     dateValue
+	interval time.Duration
+	sink     string
+	output   pathValue
+	url      string
+	sport    sportValue
+	source   string
+	dsn      string
+	fetch    fetchOptions
+	mu       sync.Mutex
+	readAt   time.Time
+	statuses map[int64]string
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (ws *WatchScoreboard) SetFlags(fs *flag.FlagSet) {
+	dateFlag(fs, &ws.date, "date", "today", "The `date` to follow")
+	fs.DurationVar(&ws.interval, "interval", 30*time.Second, "Time between reads of the scoreboard")
+	fs.StringVar(&ws.sink, "sink", "screen", "Destination of the records (screen, file or db)")
+	pathFlag(fs, &ws.output, "output", "", "Output `directory` for the file sink")
+	fs.StringVar(&ws.url, "url", "http://gd2.mlb.com", "Source location of data to download")
+	sportFlag(fs, &ws.sport, "sport", catalog.DefaultSport, catalog.SportHelp())
+	fs.StringVar(&ws.source, "source", "http", util.SourceHelp)
+	fs.StringVar(&ws.dsn, "db", "", "Database connection string for the db sink (default from the config file)")
+	ws.fetch.setFlags(fs)
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (ws *WatchScoreboard) Execute(ctx context.Context, args []string) error {
+	ws.sink = strings.ToLower(ws.sink)
+	ws.statuses = make(map[int64]string)
+
+	date, err := singleDate(ws.date.String())
+	if err != nil {
+		return usageErrorf("unable to determine the date to watch: %w", err)
+	}
+	if ws.interval <= 0 {
+		return usageErrorf("invalid interval %s", ws.interval)
+	}
+
+	client, err := ws.fetch.newFetcher(ws.source)
+	if err != nil {
+		return err
+	}
+
+	scoreboardStage := &pipelinestage.ScoreBoardFile{
+		DataInput:  make(chan string),
+		ReadOutput: make(chan pipelinestage.ScoreboardRead),
+		Client:     client,
+	}
+	scoreboardStage.Init(ctx)
+
+	filterStage := &pipelinestage.ChangeFilter{
+		DataInput: scoreboardStage.DataOutput,
+	}
+	filterStage.Init(ctx)
+
+	// The sink reads the changes after the watch has dated them with the
+	//	read they came from
+	sinkInput := make(chan string)
+	sinkStage, err := newSinkStage(ws.sink, ws.output.String(), ws.dsn, sinkInput)
+	if err == nil {
+		err = sinkStage.Init(ctx)
+	}
+	if err != nil {
+		scoreboardStage.Abort()
+		filterStage.Abort()
+		return err
+	}
+
+	var helperWG sync.WaitGroup
+	helperWG.Add(2)
+	go func() {
+		defer helperWG.Done()
+		for range scoreboardStage.GameFileOutout {
+		}
+	}()
+	go func() {
+		defer helperWG.Done()
+		for record := range filterStage.DataOutput {
+			select {
+			case sinkInput <- ws.stamp(record):
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	stages := []pipelinestage.Controller{scoreboardStage, filterStage, sinkStage}
+	for _, stage := range stages {
+		go stage.Run()
+	}
+
+	dayURL := dateToPath(ws.url, ws.sport.String(), date)
+	summary.FromContext(ctx).Add(summary.DatesRequested, 1)
+	slog.Info("Watching the scoreboard", "date", date.Format("20060102"), "sport", ws.sport.String(), "interval", ws.interval, "sink", ws.sink)
+	ws.poll(ctx, dayURL, scoreboardStage.DataInput, scoreboardStage.ReadOutput)
+
+	// The helpers stop once the channels they read are closed, and the sink
+	//	is stopped last so that it receives every change
+	scoreboardStage.Stop()
+	close(scoreboardStage.GameFileOutout)
+	filterStage.Stop()
+	close(filterStage.DataOutput)
+	helperWG.Wait()
+	sinkStage.Stop()
+	return nil
+}
+
+/*
+poll asks for the scoreboard once per interval until every game is over or
+	the context is cancelled.  Each read is finished before the next one is
+	asked for, so the statuses it found are always the latest.
+*/
+func (ws *WatchScoreboard) poll(ctx context.Context, dayURL string, scoreboards chan string, reads chan pipelinestage.ScoreboardRead) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		ws.mu.Lock()
+		ws.readAt = time.Now().UTC()
+		ws.mu.Unlock()
+		select {
+		case scoreboards <- dayURL:
+		case <-ctx.Done():
+			return
+		}
+
+		var read pipelinestage.ScoreboardRead
+		select {
+		case read = <-reads:
+		case <-ctx.Done():
+			return
+		}
+		if read.Err == nil {
+			ws.noteStatuses(read.Statuses)
+		}
+		if ws.over(read.Err == nil) {
+			slog.Info("Every game is over")
+			return
+		}
+
+		timer.Reset(ws.interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+/*
+noteStatuses remembers the status of each game as it changes
+*/
+func (ws *WatchScoreboard) noteStatuses(statuses map[int64]string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for id, status := range statuses {
+		if previous, ok := ws.statuses[id]; ok == false || previous != status {
+			slog.Info("Game status", "game", id, "status", status)
+		}
+		ws.statuses[id] = status
+	}
+}
+
+/*
+stamp dates a changed game status or inning score with the time of the read
+	it came from.  The scoreboard gives both the time of the game, which is
+	the same on every read, and their tables only take a version that is
+	later than the one they have.
+*/
+func (ws *WatchScoreboard) stamp(record string) string {
+	ws.mu.Lock()
+	readAt := ws.readAt
+	ws.mu.Unlock()
+
+	var stamped interface{}
+	switch name, _ := records.Name(record); name {
+	case "GameStatusRecord":
+		var gsR records.GameStatusRecord
+		if json.Unmarshal([]byte(record), &gsR) != nil {
+			return record
+		}
+		gsR.EffectiveDate = readAt
+		for i := range gsR.Innings {
+			gsR.Innings[i].EffectiveDate = readAt
+		}
+		stamped = gsR
+	case "InningScoreRecord":
+		var isR records.InningScoreRecord
+		if json.Unmarshal([]byte(record), &isR) != nil {
+			return record
+		}
+		isR.EffectiveDate = readAt
+		stamped = isR
+	default:
+		return record
+	}
+	data, err := json.Marshal(stamped)
+	if err != nil {
+		return record
+	}
+	return string(data)
+}
+
+/*
+over is true once every game on the scoreboard has finished.  A scoreboard
+	that was read successfully and has no games at all is over as well.
+*/
+func (ws *WatchScoreboard) over(readScoreboard bool) bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if len(ws.statuses) == 0 {
+		return readScoreboard
+	}
+	for _, status := range ws.statuses {
+		if finishedStatuses[status] == false {
+			return false
+		}
+	}
+	return true
+}
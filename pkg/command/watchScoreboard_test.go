@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func TestWatchScoreboard(t *testing.T) {
+	// The game is in the second inning on the first read and over on the second
+	var reads int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, innings := "In Progress", `<inning away="1" home="0"/><inning away="0" home="0"/>`
+		if atomic.AddInt32(&reads, 1) > 1 {
+			status, innings = "Final", `<inning away="1" home="0"/><inning away="0" home="2"/><inning away="2" home="0"/>`
+		}
+		fmt.Fprintf(w, `<games year="2019" month="06" day="10">
+			<game game_pk="565000" game_data_directory="/components/game/mlb/year_2019/month_06/day_10/gid_2019_06_10_nyamlb_bosmlb_1"
+				time_date="2019/06/10 7:10" ampm="PM" time_zone="ET" venue_id="3"
+				away_team_id="147" away_league_id="103" away_division="E" home_team_id="111" home_league_id="103" home_division="E">
+				<status status="%s"/><linescore>%s</linescore></game></games>`, status, innings)
+	}))
+	defer ts.Close()
+
+	dsn := "sqlite:" + filepath.Join(t.TempDir(), "baseball.db")
+	ws := &WatchScoreboard{}
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	ws.SetFlags(fs)
+	err := fs.Parse([]string{"-date", "20190610", "-interval", "10ms", "-sink", "db", "-db", dsn, "-url", ts.URL, "-workers", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := ws.Execute(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("The watch did not stop when the game was over")
+	}
+	if reads != 2 {
+		t.Errorf("Expected the scoreboard to be read twice, it was read %d times", reads)
+	}
+
+	// Both reads have the same game time, and the second one still wins
+	db, err := util.GetDBConnection(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var status string
+	var homeRuns int
+	err = db.QueryRowContext(ctx, `SELECT status FROM GameStatusRecord WHERE id=565000`).Scan(&status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != "Final" {
+		t.Errorf("Expected the final status to be loaded, found %s", status)
+	}
+	err = db.QueryRowContext(ctx, `SELECT homeTeamRuns FROM InningScoreRecord WHERE gameID=565000 AND inning=2`).Scan(&homeRuns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if homeRuns != 2 {
+		t.Errorf("Expected the 2 home runs of the second inning to be loaded, found %d", homeRuns)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	Register(Registration{
+		Name:      "completion",
+		Synopsis:  "Write the shell completion script for bash, zsh or fish",
+		Args:      "<shell>",
+		Complete:  func() []string { return []string{"bash", "zsh", "fish"} },
+		New:       func() Command { return &WriteCompletion{} },
+		NoSummary: true,
+	})
+}
+
+/*
+WriteCompletion writes a shell completion script that is generated from the
+	registered commands and their flags, so it never falls behind
+*/
+type WriteCompletion struct {
+}
+
+/*
+completionCommand is what the scripts need to know about a command
+*/
+type completionCommand struct {
+	name     string
+	synopsis string
+	words    []string
+	flags    []*flag.Flag
+}
+
+/*
+SetFlags creates the flags that are needed for this functionality
+*/
+func (wc *WriteCompletion) SetFlags(fs *flag.FlagSet) {
+}
+
+/*
+Execute runs the functionality that produces the data needed
+*/
+func (wc *WriteCompletion) Execute(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("completion takes a single shell: bash, zsh or fish")
+	}
+	commands := completionCommands()
+	switch strings.ToLower(args[0]) {
+	case "bash":
+		writeBashCompletion(os.Stdout, commands)
+	case "zsh":
+		fmt.Println("#compdef baseball")
+		fmt.Println("autoload -U +X bashcompinit && bashcompinit")
+		writeBashCompletion(os.Stdout, commands)
+	case "fish":
+		writeFishCompletion(os.Stdout, commands)
+	default:
+		return usageErrorf("unknown shell %s (expected bash, zsh or fish)", args[0])
+	}
+	return nil
+}
+
+func completionCommands() []completionCommand {
+	var commands []completionCommand
+	for _, r := range Registered() {
+		fs, _ := NewFlagSet(r, &Globals{})
+		cc := completionCommand{name: r.Name, synopsis: r.Synopsis}
+		if r.Complete != nil {
+			cc.words = r.Complete()
+		}
+		fs.VisitAll(func(f *flag.Flag) {
+			cc.flags = append(cc.flags, f)
+		})
+		commands = append(commands, cc)
+	}
+	return commands
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func isPathFlag(f *flag.Flag) bool {
+	_, ok := f.Value.(*pathValue)
+	return ok
+}
+
+func writeBashCompletion(w io.Writer, commands []completionCommand) {
+	var names []string
+	for _, cc := range commands {
+		names = append(names, cc.name)
+	}
+
+	fmt.Fprintln(w, "# bash completion for baseball: source <(baseball completion bash)")
+	fmt.Fprintln(w, "_baseball() {")
+	fmt.Fprintln(w, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"")
+	fmt.Fprintln(w, "\tif [ \"$COMP_CWORD\" -eq 1 ]; then")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "\tlocal flags=\"\" paths=\"\" words=\"\"")
+	fmt.Fprintln(w, "\tcase \"${COMP_WORDS[1]}\" in")
+	for _, cc := range commands {
+		var flags, paths []string
+		for _, f := range cc.flags {
+			flags = append(flags, "-"+f.Name)
+			if isPathFlag(f) {
+				paths = append(paths, "-"+f.Name)
+			}
+		}
+		fmt.Fprintf(w, "\t%s)\n", cc.name)
+		fmt.Fprintf(w, "\t\tflags=%q\n", strings.Join(flags, " "))
+		fmt.Fprintf(w, "\t\tpaths=%q\n", strings.Join(paths, " "))
+		fmt.Fprintf(w, "\t\twords=%q\n", strings.Join(cc.words, " "))
+		fmt.Fprintln(w, "\t\t;;")
+	}
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "\tcase \" $paths \" in")
+	fmt.Fprintln(w, "\t*\" $prev \"*)")
+	fmt.Fprintln(w, "\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))")
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\t\t;;")
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "\tif [[ \"$cur\" == -* ]]; then")
+	fmt.Fprintln(w, "\t\tCOMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))")
+	fmt.Fprintln(w, "\telse")
+	fmt.Fprintln(w, "\t\tCOMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _baseball baseball")
+}
+
+func writeFishCompletion(w io.Writer, commands []completionCommand) {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+	}
+
+	fmt.Fprintln(w, "# fish completion for baseball: baseball completion fish | source")
+	fmt.Fprintln(w, "complete -c baseball -f")
+	for _, cc := range commands {
+		fmt.Fprintf(w, "complete -c baseball -n __fish_use_subcommand -a %s -d %s\n", cc.name, quote(cc.synopsis))
+	}
+	for _, cc := range commands {
+		condition := quote("__fish_seen_subcommand_from " + cc.name)
+		if len(cc.words) > 0 {
+			fmt.Fprintf(w, "complete -c baseball -n %s -a %s\n", condition, quote(strings.Join(cc.words, " ")))
+		}
+		for _, f := range cc.flags {
+			_, usage := flag.UnquoteUsage(f)
+			option := ""
+			switch {
+			case isPathFlag(f):
+				option = " -r -F"
+			case isBoolFlag(f) == false:
+				option = " -r"
+			}
+			fmt.Fprintf(w, "complete -c baseball -n %s -o %s%s -d %s\n", condition, f.Name, option, quote(usage))
+		}
+	}
+}
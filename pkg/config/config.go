@@ -0,0 +1,356 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+/*
+The names of the settings, which are also their locations in the config file
+*/
+const (
+	DatabaseDSN    = "database.dsn"
+	DataRoot       = "data.root"
+	DataCompress   = "data.compress"
+	GamedayURL     = "sources.gameday"
+	SavantURL      = "sources.savant"
+	GamedaySport   = "sources.sport"
+	FetchRate      = "fetch.rate"
+	FetchBurst     = "fetch.burst"
+	FetchWorkers   = "fetch.workers"
+	FetchRetries   = "fetch.retries"
+	FetchBackoff   = "fetch.backoff"
+	FetchTimeout   = "fetch.timeout"
+	DefaultDate    = "dates.default"
+	sourceDefault  = "default"
+	configFileName = "config.toml"
+)
+
+/*
+definition describes a setting, the environment variable that overrides it and
+	its built in default
+*/
+type definition struct {
+	name  string
+	env   string
+	value string
+}
+
+var definitions = []definition{
+	{DatabaseDSN, "BASEBALL_DB_DSN", "postgres://localhost/baseball?sslmode=disable"},
+	{DataRoot, "BASEBALL_DATA_ROOT", DefaultDataRoot()},
+	{DataCompress, "BASEBALL_COMPRESS", "none"},
+	{GamedayURL, "BASEBALL_GAMEDAY_URL", "http://gd2.mlb.com"},
+	{SavantURL, "BASEBALL_SAVANT_URL", "https://baseballsavant.mlb.com"},
+	{GamedaySport, "BASEBALL_SPORT", "mlb"},
+	{FetchRate, "BASEBALL_RATE", "1"},
+	{FetchBurst, "BASEBALL_BURST", "1"},
+	{FetchWorkers, "BASEBALL_WORKERS", "4"},
+	{FetchRetries, "BASEBALL_RETRIES", "3"},
+	{FetchBackoff, "BASEBALL_BACKOFF", "1s"},
+	{FetchTimeout, "BASEBALL_TIMEOUT", "45s"},
+	{DefaultDate, "BASEBALL_DATE", "yesterday"},
+}
+
+/*
+flagSettings maps the flags shared by the commands to the settings that supply
+	their values when they aren't given on the command line.  The url flag
+	depends on the command, see Flags.
+*/
+var flagSettings = map[string]string{
+	"db":          DatabaseDSN,
+	"output":      DataRoot,
+	"compress":    DataCompress,
+	"rate":        FetchRate,
+	"burst":       FetchBurst,
+	"workers":     FetchWorkers,
+	"retries":     FetchRetries,
+	"backoff":     FetchBackoff,
+	"timeout":     FetchTimeout,
+	"date":        DefaultDate,
+	"savant-url":  SavantURL,
+	"gameday-url": GamedayURL,
+	"sport":       GamedaySport,
+}
+
+/*
+dataRootCommands are the commands whose output flag is the data root.  Other
+	commands use their output flag for something else.
+*/
+var dataRootCommands = map[string]bool{
+	"savant":   true,
+	"gameday":  true,
+	"pipeline": true,
+	"watch":    true,
+	"sync":     true,
+	"verify":   true,
+	"compress": true,
+}
+
+/*
+liveCommands follow today's games, so the default date doesn't apply to them
+*/
+var liveCommands = map[string]bool{
+	"watch": true,
+}
+
+/*
+Setting is the effective value of a setting and where it came from: the
+	default, the config file or an environment variable
+*/
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+/*
+Config holds the effective settings.  Values are resolved in order of
+	precedence: environment variables, then the config file, then the
+	built in defaults.  Flags given on the command line beat all of them,
+	which is handled by the caller through Flags.
+*/
+type Config struct {
+	Path     string
+	settings map[string]Setting
+}
+
+/*
+DefaultPath is the config file used when no other file is given,
+	normally ~/.config/baseball/config.toml
+*/
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "baseball", configFileName)
+}
+
+/*
+DefaultDataRoot is the directory data is stored in when nothing else has been
+	configured, normally ~/baseball
+*/
+func DefaultDataRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "baseball"
+	}
+	return filepath.Join(home, "baseball")
+}
+
+/*
+Load reads the config file and the environment.  An empty path means the
+	default config file, which doesn't have to exist; a file that was asked
+	for by name does.
+*/
+func Load(path string) (*Config, error) {
+	c := &Config{Path: path, settings: make(map[string]Setting)}
+	for _, d := range definitions {
+		c.settings[d.name] = Setting{Name: d.name, Value: d.value, Source: sourceDefault}
+	}
+
+	if len(path) == 0 {
+		c.Path = DefaultPath()
+		if _, err := os.Stat(c.Path); err != nil {
+			c.Path = ""
+		}
+	}
+	if len(c.Path) > 0 {
+		err := c.loadFile(c.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.loadEnv()
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	var file map[string]interface{}
+	_, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return fmt.Errorf("unable to read the config file %s: %w", path, err)
+	}
+
+	for section, values := range file {
+		table, ok := values.(map[string]interface{})
+		if ok == false {
+			return fmt.Errorf("%s: %s should be a table", path, section)
+		}
+		for key, value := range table {
+			name := section + "." + key
+			if _, ok := c.settings[name]; ok == false {
+				return fmt.Errorf("%s: unknown setting %s", path, name)
+			}
+			c.settings[name] = Setting{Name: name, Value: fmt.Sprint(value), Source: path}
+		}
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() {
+	for _, d := range definitions {
+		if value, ok := os.LookupEnv(d.env); ok {
+			c.settings[d.name] = Setting{Name: d.name, Value: value, Source: "env " + d.env}
+		}
+	}
+	if _, ok := os.LookupEnv("BASEBALL_DB_DSN"); ok == false {
+		if dsn, source, ok := legacyDSN(); ok {
+			c.settings[DatabaseDSN] = Setting{Name: DatabaseDSN, Value: dsn, Source: source}
+		}
+	}
+}
+
+/*
+legacyDSN builds a connection string from the variables that were used before
+	there was a config file: BASEBALL_DB_USER, _PASS, _NAME and _HOST, or
+	BBALL_USER, _PASS, _DBNAME and _SSLMODE
+*/
+func legacyDSN() (string, string, bool) {
+	for _, prefix := range []string{"BASEBALL_DB", "BBALL"} {
+		user, ok := os.LookupEnv(prefix + "_USER")
+		if ok == false {
+			continue
+		}
+		dbname := os.Getenv(prefix + "_NAME")
+		if len(dbname) == 0 {
+			dbname = os.Getenv(prefix + "_DBNAME")
+		}
+		host := os.Getenv(prefix + "_HOST")
+		if len(host) == 0 {
+			host = "localhost"
+		}
+		sslMode := os.Getenv(prefix + "_SSLMODE")
+		if len(sslMode) == 0 {
+			sslMode = "disable"
+		}
+		dsn := fmt.Sprintf("user='%s' password='%s' dbname='%s' host='%s' sslmode='%s'",
+			user, os.Getenv(prefix+"_PASS"), dbname, host, sslMode)
+		return dsn, "env " + prefix + "_*", true
+	}
+	return "", "", false
+}
+
+/*
+Get returns the effective value of a setting
+*/
+func (c *Config) Get(name string) string {
+	return c.settings[name].Value
+}
+
+/*
+Settings returns every setting, in the order they appear in the config file
+*/
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, 0, len(definitions))
+	for _, d := range definitions {
+		settings = append(settings, c.settings[d.name])
+	}
+	return settings
+}
+
+/*
+Flags returns the values the config supplies for the flags of a command,
+	keyed by flag name
+*/
+func (c *Config) Flags(command string) map[string]string {
+	flags := make(map[string]string)
+	for flag, name := range flagSettings {
+		flags[flag] = c.Get(name)
+	}
+	if dataRootCommands[command] == false {
+		delete(flags, "output")
+	}
+	if liveCommands[command] {
+		delete(flags, "date")
+	}
+	if command == "savant" {
+		flags["url"] = c.Get(SavantURL)
+	} else {
+		flags["url"] = c.Get(GamedayURL)
+	}
+	return flags
+}
+
+var dsnPassword = regexp.MustCompile(`password=('[^']*'|\S*)`)
+
+/*
+Redacted returns the value of a setting with any database password hidden, so
+	that it can be shown to the user
+*/
+func (s Setting) Redacted() string {
+	if s.Name != DatabaseDSN {
+		return s.Value
+	}
+	if u, err := url.Parse(s.Value); err == nil && len(u.Scheme) > 0 {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(s.Value, "password=xxxxx")
+}
+
+/*
+Write prints the settings in the format of the config file, noting where each
+	value came from
+*/
+func (c *Config) Write(w io.Writer) {
+	sections := make(map[string][]Setting)
+	var order []string
+	for _, s := range c.Settings() {
+		section := s.Name[:strings.Index(s.Name, ".")]
+		if _, ok := sections[section]; ok == false {
+			order = append(order, section)
+		}
+		sections[section] = append(sections[section], s)
+	}
+	for i, section := range order {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "[%s]\n", section)
+		for _, s := range sections[section] {
+			fmt.Fprintf(w, "%s = %q # %s\n", s.Name[len(section)+1:], s.Redacted(), s.Source)
+		}
+	}
+}
+
+type contextKey struct{}
+
+/*
+NewContext returns a context that carries the config
+*/
+func NewContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+/*
+FromContext returns the config carried by the context, or nil
+*/
+func FromContext(ctx context.Context) *Config {
+	c, _ := ctx.Value(contextKey{}).(*Config)
+	return c
+}
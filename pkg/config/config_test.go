@@ -0,0 +1,96 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[data]
+root = "/data/baseball"
+
+[fetch]
+rate = 2
+workers = 8
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BASEBALL_WORKERS", "16")
+	// Setenv restores the variables after the test; they have to be missing,
+	//	not just empty, for the older variables to be used
+	for _, name := range []string{"BASEBALL_DB_DSN", "BASEBALL_DB_USER"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("BBALL_USER", "bauer")
+	t.Setenv("BBALL_PASS", "secret")
+	t.Setenv("BBALL_DBNAME", "baseball")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var settingTest = []struct {
+		Name           string
+		ExpectedValue  string
+		ExpectedSource string
+	}{
+		{DataRoot, "/data/baseball", path},
+		{FetchRate, "2", path},
+		{FetchWorkers, "16", "env BASEBALL_WORKERS"},
+		{FetchBurst, "1", "default"},
+		{DatabaseDSN, "user='bauer' password='secret' dbname='baseball' host='localhost' sslmode='disable'", "env BBALL_*"},
+	}
+	for _, tt := range settingTest {
+		s := cfg.settings[tt.Name]
+		if s.Value != tt.ExpectedValue || s.Source != tt.ExpectedSource {
+			t.Errorf("%s: expected %s from %s, got %s from %s", tt.Name, tt.ExpectedValue, tt.ExpectedSource, s.Value, s.Source)
+		}
+	}
+
+	if redacted := cfg.settings[DatabaseDSN].Redacted(); redacted != "user='bauer' password=xxxxx dbname='baseball' host='localhost' sslmode='disable'" {
+		t.Errorf("The password was not hidden: %s", redacted)
+	}
+	if flags := cfg.Flags("savant"); flags["url"] != "https://baseballsavant.mlb.com" || flags["output"] != "/data/baseball" {
+		t.Errorf("Unexpected flags for savant: %v", flags)
+	}
+	if _, ok := cfg.Flags("weather")["output"]; ok {
+		t.Errorf("The data root should not be used for the weather output")
+	}
+}
+
+func TestLoadUnknownSetting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(path, []byte("[fetch]\nspeed = 2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("Expected an error for an unknown setting")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Errorf("Expected an error for a missing config file")
+	}
+}
@@ -17,11 +17,14 @@
 package datepath
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 	"golang.org/x/net/html"
 )
 
@@ -32,7 +35,9 @@ DatePath turns a single date path into the paths of all
 type DatePath struct {
 	DatePath chan string
 	FilePath chan string
-	reqWG    sync.WaitGroup
+	// Files are the per-game files to publish; util.DefaultGameFiles when empty
+	Files []util.GameFile
+	reqWG sync.WaitGroup
 }
 
 /*
@@ -42,25 +47,44 @@ ChannelListener should be run in a goroutine and will receive paths on the DateP
 	to all the files that we care about.  To end this goroutine, close the DatePath
 	channel.  When that happens, we wait for all outstanding URL requests to finish
 	and the paths to be published on the FilePath channel.  Once that finishes, the
-	goroutine exits.
+	goroutine exits.  A date whose page can't be retrieved, including one the
+	server answers with an error status, is a failure.  When the context is
+	cancelled, the remaining dates are drained without being requested.
 */
-func (fP *DatePath) ChannelListener(client *http.Client) {
+func (fP *DatePath) ChannelListener(ctx context.Context, client util.Getter) {
 	for inputPath := range fP.DatePath {
+		if ctx.Err() != nil {
+			continue
+		}
 		fP.reqWG.Add(1)
-		fmt.Printf("\t\tRequesting %s\n", inputPath)
-		resp, err := client.Get(inputPath)
+		results := summary.FromContext(ctx)
+		results.Request(inputPath)
+		slog.Info("Requesting date", "url", inputPath)
+		resp, err := util.GetContext(ctx, client, inputPath)
 		if err != nil {
-			fmt.Println(err.Error())
+			slog.Error("Unable to retrieve date", "url", inputPath, "err", err)
+			results.Fail(inputPath, err)
+			fP.reqWG.Done()
+			continue
+		}
+		// An error page would read as a day without games
+		if err = util.CheckResponse(resp); err != nil {
+			resp.Body.Close()
+			slog.Error("Unable to retrieve date", "url", inputPath, "err", err)
+			results.Fail(inputPath, err)
+			fP.reqWG.Done()
+			continue
 		}
-		fP.tokenize(inputPath, resp)
+		fP.tokenize(ctx, inputPath, resp)
+		results.Done(inputPath)
 	}
-	fmt.Println("FilePath input channel has closed; waiting for all requests to finish")
+	slog.Debug("DatePath input channel has closed; waiting for all requests to finish")
 	fP.reqWG.Wait()
-	fmt.Println("All requests have finished")
+	slog.Debug("All date requests have finished")
 	close(fP.FilePath)
 }
 
-func (fP *DatePath) tokenize(dataPath string, resp *http.Response) {
+func (fP *DatePath) tokenize(ctx context.Context, dataPath string, resp *http.Response) {
 	defer resp.Body.Close()
 
 	if strings.HasSuffix(dataPath, "/") == false {
@@ -89,11 +113,17 @@ func (fP *DatePath) tokenize(dataPath string, resp *http.Response) {
 								gidPath = gidPath + "/"
 							}
 
-							//fP.FilePath <- gidPath + "bis_boxscore.xml"
-							//fP.FilePath <- gidPath + "game.xml"
-							//fP.FilePath <- gidPath + "game_events.xml"
-							fP.FilePath <- gidPath + "inning/inning_all.xml"
-							//fP.FilePath <- gidPath + "inning/inning_hit.xml"
+							for j, gameFile := range fP.Files {
+								if j == 0 {
+									summary.FromContext(ctx).Add(summary.GamesFound, 1)
+								}
+								select {
+								case fP.FilePath <- gidPath + gameFile.Path:
+								case <-ctx.Done():
+									fP.reqWG.Done()
+									return
+								}
+							}
 						}
 						break
 					}
@@ -109,6 +139,9 @@ Init will create all channels
 func (fP *DatePath) Init() {
 	fP.DatePath = make(chan string)
 	fP.FilePath = make(chan string)
+	if len(fP.Files) == 0 {
+		fP.Files, _ = util.SelectGameFiles(util.DefaultGameFiles)
+	}
 }
 
 /*
@@ -0,0 +1,67 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package datepath
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func TestDatePath(t *testing.T) {
+	ts := httptest.NewServer(fixtures.New(fixtures.Default(), fixtures.Fault{Kind: fixtures.FaultServerError, Match: "day_11"}))
+	defer ts.Close()
+
+	var datePathTest = []struct {
+		Path          string
+		ExpectedFiles int
+		ExpectedFail  bool
+	}{
+		{"/components/game/mlb/year_2019/month_06/day_10", 1, false},
+		{"/components/game/mlb/year_2019/month_06/day_11", 0, true},
+		{"/nonexistent", 0, true},
+	}
+
+	for _, ex := range datePathTest {
+		results := summary.New("gameday")
+		ctx := summary.NewContext(context.Background(), results)
+		files, _ := util.SelectGameFiles("inning_all")
+		dP := DatePath{Files: files}
+		dP.Init()
+		go dP.ChannelListener(ctx, http.DefaultClient)
+		go func() {
+			dP.DatePath <- ts.URL + ex.Path
+			dP.Done()
+		}()
+
+		var paths []string
+		for path := range dP.FilePath {
+			paths = append(paths, path)
+		}
+		if len(paths) != ex.ExpectedFiles {
+			t.Errorf("Expected %d files for %s, received %v", ex.ExpectedFiles, ex.Path, paths)
+		}
+		if (results.Failed() == 1) != ex.ExpectedFail {
+			t.Errorf("Expected a failure for %s: %v, received %d failures", ex.Path, ex.ExpectedFail, results.Failed())
+		}
+	}
+}
@@ -17,7 +17,7 @@
 package dateslice
 
 import (
-	"fmt"
+	"log/slog"
 	"math"
 	"strconv"
 	"strings"
@@ -106,7 +106,7 @@ func aMonth(baseDate time.Time) []time.Time {
 
 	firstOfNextMonth := baseDate.AddDate(0, 1, 0)
 	daysInThisMonth := firstOfNextMonth.Sub(baseDate).Hours() / 24.0
-	fmt.Printf("%f days in the month\n", math.Ceil(daysInThisMonth))
+	slog.Debug("Days in the month", "days", math.Ceil(daysInThisMonth))
 
 	ds := make([]time.Time, int(math.Ceil(daysInThisMonth)))
 
@@ -147,7 +147,7 @@ func aYear(baseDate time.Time) []time.Time {
 
 	firstOfNextYear := baseDate.AddDate(1, 0, 0)
 	daysInThisYear := firstOfNextYear.Sub(baseDate).Hours() / 24.0
-	fmt.Printf("%f days in the year\n", math.Ceil(daysInThisYear))
+	slog.Debug("Days in the year", "days", math.Ceil(daysInThisYear))
 
 	ds := make([]time.Time, int(math.Ceil(daysInThisYear)))
 
@@ -192,7 +192,7 @@ func RangeString(begDt, endDt string) []time.Time {
 
 		temp, err := time.Parse("20060102", endDt)
 		if err != nil {
-			fmt.Println(err)
+			slog.Error("Invalid end date", "date", endDt, "err", err)
 		}
 		temp = temp.AddDate(0, 1, 0)
 		temp = temp.AddDate(0, 0, -1)
@@ -204,7 +204,7 @@ func RangeString(begDt, endDt string) []time.Time {
 
 		temp, err := time.Parse("20060102", endDt)
 		if err != nil {
-			fmt.Println(err)
+			slog.Error("Invalid end date", "date", endDt, "err", err)
 		}
 		temp = temp.AddDate(1, 0, 0)
 		temp = temp.AddDate(0, 0, -1)
@@ -212,11 +212,11 @@ func RangeString(begDt, endDt string) []time.Time {
 	}
 	beg, err := time.Parse("20060102", begDt)
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("Invalid beginning date", "date", begDt, "err", err)
 	}
 	end, err := time.Parse("20060102", endDt)
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("Invalid end date", "date", endDt, "err", err)
 	}
 	return Range(beg, end)
 }
@@ -17,37 +17,35 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"encoding/csv"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"log"
-	"os"
+	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
 BaseballDB holds data that allows interaction with a database
 */
 type BaseballDB struct {
-	dbConn *sql.DB
+	dbConn *util.Database
 }
 
 /*
-ConnectToPostgres does something
+Connect opens the database described by a connection string (see
+	util.GetDBConnection)
 */
-func (bdb *BaseballDB) ConnectToPostgres(prefix string) error {
-	pWord := os.Getenv(prefix + "_PASS")
-	uName := os.Getenv(prefix + "_USER")
-	dbName := os.Getenv(prefix + "_DBNAME")
-	sslMode := os.Getenv(prefix + "_SSLMODE")
-	cString := fmt.Sprintf("user=%s dbname=%s password='%s' sslmode=%s",
-		uName, dbName, pWord, sslMode)
-	db, err := sql.Open("postgres", cString)
+func (bdb *BaseballDB) Connect(dsn string) error {
+	db, err := util.GetDBConnection(dsn)
 	if err != nil {
 		return err
 	}
@@ -58,8 +56,8 @@ func (bdb *BaseballDB) ConnectToPostgres(prefix string) error {
 /*
 ConfirmSavantMaster makes sure that the table is present.  If not, create it.
 */
-func (bdb *BaseballDB) ConfirmSavantMaster() error {
-	_, err := bdb.dbConn.Exec(`create table if not exists mlb_savant (
+func (bdb *BaseballDB) ConfirmSavantMaster(ctx context.Context) error {
+	err := bdb.dbConn.CreateTable(ctx, `create table if not exists mlb_savant (
 		pitch_type text,
 		game_date date,
 		release_speed double precision,
@@ -156,29 +154,34 @@ func (bdb *BaseballDB) ConfirmSavantMaster() error {
 }
 
 /*
-LoadSavantCSV takes a CSV file and bulk loads it into the database
+LoadSavantCSV takes a CSV file, plain or compressed (see util.OpenFile), and
+	bulk loads it into the database.  The whole file is loaded in a single
+	transaction, which is rolled back if anything goes wrong, including the
+	context being cancelled part way through.
 */
-func (bdb *BaseballDB) LoadSavantCSV(f string) error {
-	fp, err := os.Open(f)
+func (bdb *BaseballDB) LoadSavantCSV(ctx context.Context, f string) error {
+	fp, err := util.OpenFile(f)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer fp.Close()
 	r := csv.NewReader(fp)
 
-	txn, err := bdb.dbConn.Begin()
+	txn, err := bdb.dbConn.BeginTx(ctx, nil)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	// Rollback does nothing once the transaction has been committed
+	defer txn.Rollback()
 
 	header, err := r.Read()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	dblRecord := make([]float64, len(header))
 	intRecord := make([]int, len(header))
 
-	stmt, err := txn.Prepare(pq.CopyIn("mlb_savant", "pitch_type",
+	stmt, err := txn.PrepareContext(ctx, bdb.dbConn.CopyIn("mlb_savant", "pitch_type",
 		"game_date", "release_speed", "release_pos_x",
 		"release_pos_z", "player_name",
 		"batter", "pitcher", "events", "description",
@@ -207,6 +210,7 @@ func (bdb *BaseballDB) LoadSavantCSV(f string) error {
 		return err
 	}
 
+	rows := 0
 	for {
 		record, err := r.Read()
 		if err == io.EOF {
@@ -232,7 +236,7 @@ func (bdb *BaseballDB) LoadSavantCSV(f string) error {
 				} else {
 					dblRecord[i], err = strconv.ParseFloat(record[i], 64)
 					if err != nil {
-						log.Printf("%d -> %s\n", i, err)
+						slog.Warn("Unable to parse a savant column", "file", f, "column", header[i], "err", err)
 						dblRecord[i] = -99.0
 					}
 				}
@@ -244,13 +248,13 @@ func (bdb *BaseballDB) LoadSavantCSV(f string) error {
 				} else {
 					intRecord[i], err = strconv.Atoi(record[i])
 					if err != nil {
-						log.Printf("%d -> %s\n", i, err)
+						slog.Warn("Unable to parse a savant column", "file", f, "column", header[i], "err", err)
 						intRecord[i] = -99
 					}
 				}
 			}
 		}
-		_, err = stmt.Exec(record[0], record[1], dblRecord[2],
+		_, err = stmt.ExecContext(ctx, record[0], record[1], dblRecord[2],
 			dblRecord[3], dblRecord[4], record[5], intRecord[6], intRecord[7],
 			record[8], record[9], dblRecord[10], dblRecord[11], dblRecord[12],
 			dblRecord[13], intRecord[14], record[15], record[16], record[17],
@@ -271,9 +275,10 @@ func (bdb *BaseballDB) LoadSavantCSV(f string) error {
 		if err != nil {
 			return err
 		}
+		rows++
 	}
 
-	_, err = stmt.Exec()
+	err = bdb.dbConn.FinishCopy(ctx, stmt)
 	if err != nil {
 		return err
 	}
@@ -287,6 +292,7 @@ func (bdb *BaseballDB) LoadSavantCSV(f string) error {
 	if err != nil {
 		return err
 	}
+	summary.FromContext(ctx).Add(summary.RowsLoaded("mlb_savant"), int64(rows))
 	return nil
 }
 
@@ -306,10 +312,13 @@ func (bdb *BaseballDB) DropSavantTable() error {
 }
 
 /*
-ConfirmGamedayMaster makes sure that the table is present.  If not, create it.
+ConfirmGamedayMaster makes sure that the tables loaded from inning_all files,
+	mlb_gameday, mlb_pitchfx, mlb_runner and mlb_pickoff, are present.  If
+	not, create them.  Each of them is keyed by the game and the at bat,
+	along with the place of the row in the at bat.
 */
-func (bdb *BaseballDB) ConfirmGamedayMaster() error {
-	_, err := bdb.dbConn.Exec(`create table if not exists mlb_gameday (
+func (bdb *BaseballDB) ConfirmGamedayMaster(ctx context.Context) error {
+	err := bdb.dbConn.CreateTable(ctx, `create table if not exists mlb_gameday (
 		game_date date,
 		away_team text,
 		home_team text,
@@ -322,9 +331,17 @@ func (bdb *BaseballDB) ConfirmGamedayMaster() error {
 		pitch_number integer,
 		sv_id text,
 		pitch_tfs integer,
-		pitch_tfs_zulu timestamp with time zone);
+		pitch_tfs_zulu timestamp with time zone,
+		primary key (game_date, away_team, home_team, game_number, at_bat_number, pitch_number));
 		`)
-	return err
+	if err != nil {
+		return err
+	}
+	err = bdb.ConfirmPitchFXTable(ctx)
+	if err != nil {
+		return err
+	}
+	return bdb.ConfirmRunnerTables(ctx)
 }
 
 /*
@@ -336,51 +353,164 @@ func (bdb *BaseballDB) DropGamedayTable() error {
 }
 
 /*
-<pitch
-break_angle="38.4"
-break_length="4.8"
-break_y="24.0"
-cc=""
-code="B"
-des="Ball"
-des_es="In play, out(s)"
-end_speed="84.1"
-event_num="2"
-id="2"
-mt=""
-nasty=""
-pitch_type="FF"
-play_guid="28c604b5-9faf-43db-b371-7535480c9a4e"
-spin_dir="placeholder"
-spin_rate="placeholder"
-start_speed="92.5"
+LoadedGames returns the games that have at least one row in mlb_savant or
+	mlb_gameday, by date (YYYYMMDD).  A game in mlb_savant is its game_pk.
+	mlb_gameday doesn't have the game_pk, so a game there is named by its
+	teams and number as in the directory of its files: nya_bos_1 for
+	gid_2019_06_10_nyamlb_bosmlb_1.
+*/
+func (bdb *BaseballDB) LoadedGames(ctx context.Context, table string) (map[string]map[string]bool, error) {
+	var game string
+	switch table {
+	case "mlb_savant":
+		game = "cast(game_pk as text)"
+	case "mlb_gameday":
+		game = "lower(away_team) || '_' || lower(home_team) || '_' || cast(game_number as text)"
+	default:
+		return nil, fmt.Errorf("%s is not a table of pitches", table)
+	}
+	// Both databases turn a date into text as YYYY-MM-DD
+	rows, err := bdb.dbConn.QueryContext(ctx, "select distinct cast(game_date as text), "+game+" from "+table+" where game_date is not null;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := make(map[string]map[string]bool)
+	for rows.Next() {
+		var gameDate string
+		var gameKey sql.NullString
+		err = rows.Scan(&gameDate, &gameKey)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ReplaceAll(gameDate, "-", "")
+		if games[key] == nil {
+			games[key] = make(map[string]bool)
+		}
+		if gameKey.Valid {
+			games[key][gameKey.String] = true
+		}
+	}
+	return games, rows.Err()
+}
+
+/*
+DeleteSavantDate removes the pitches of a date from mlb_savant, so that the
+	file of the date can be loaded again without repeating them
 */
+func (bdb *BaseballDB) DeleteSavantDate(ctx context.Context, date time.Time) error {
+	_, err := bdb.dbConn.ExecContext(ctx, "delete from mlb_savant where game_date = $1;", date.Format("2006-01-02"))
+	return err
+}
 
 /*
-PitchXML represents a pitch
+ScheduledGame is a game in the GameRecord table, with its status from the
+	GameStatusRecord table
+*/
+type ScheduledGame struct {
+	ID       int64
+	GameType string
+	Status   string
+}
+
+/*
+Played is true once the game is over, so that its pitches can be expected in
+	mlb_savant and mlb_gameday
+*/
+func (sg ScheduledGame) Played() bool {
+	switch sg.Status {
+	case "Final", "Game Over", "Completed Early":
+		return true
+	}
+	return false
+}
+
+/*
+ScheduledGames returns the games in the GameRecord table that the pipeline
+	fills in, by date (YYYYMMDD).  The tables are created if they aren't
+	there yet, so an empty schedule just means that the pipeline hasn't
+	been run.
+*/
+func (bdb *BaseballDB) ScheduledGames(ctx context.Context) (map[string][]ScheduledGame, error) {
+	err := (&records.GameRecord{}).CreateTable(ctx, bdb.dbConn)
+	if err != nil {
+		return nil, err
+	}
+	err = (&records.GameStatusRecord{}).CreateTable(ctx, bdb.dbConn)
+	if err != nil {
+		return nil, err
+	}
+	// Games are dated by MLB in the eastern time zone
+	eastern, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := bdb.dbConn.QueryContext(ctx, `select g.effectiveDate, g.id, g.gametype, s.status
+		from GameRecord g left join GameStatusRecord s on s.id = g.id
+		where g.effectiveDate is not null;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := make(map[string][]ScheduledGame)
+	for rows.Next() {
+		var start time.Time
+		var game ScheduledGame
+		var gameType, status sql.NullString
+		err = rows.Scan(&start, &game.ID, &gameType, &status)
+		if err != nil {
+			return nil, err
+		}
+		game.GameType = gameType.String
+		game.Status = status.String
+		key := start.In(eastern).Format("20060102")
+		games[key] = append(games[key], game)
+	}
+	return games, rows.Err()
+}
+
+/*
+PitchXML represents a pitch.  The acceleration at release is written as ax,
+	ay and az in the gameday files.
 */
 type PitchXML struct {
+	ID             string `xml:"id,attr"`
+	Des            string `xml:"des,attr"`
+	Code           string `xml:"code,attr"`
+	PlayGUID       string `xml:"play_guid,attr"`
 	SVID           string `xml:"sv_id,attr"`
 	TFS            string `xml:"tfs,attr"`
 	TFSZulu        string `xml:"tfs_zulu,attr"`
 	X              string `xml:"x,attr"`
 	Y              string `xml:"y,attr"`
+	StartSpeed     string `xml:"start_speed,attr"`
+	EndSpeed       string `xml:"end_speed,attr"`
 	X0             string `xml:"x0,attr"`
 	Y0             string `xml:"y0,attr"`
 	Z0             string `xml:"z0,attr"`
 	VX0            string `xml:"vx0,attr"`
 	VY0            string `xml:"vy0,attr"`
 	VZ0            string `xml:"vz0,attr"`
-	AX0            string `xml:"ax0,attr"`
-	AY0            string `xml:"ay0,attr"`
-	AZ0            string `xml:"az0,attr"`
+	AX0            string `xml:"ax,attr"`
+	AY0            string `xml:"ay,attr"`
+	AZ0            string `xml:"az,attr"`
 	PX             string `xml:"px,attr"`
 	PZ             string `xml:"pz,attr"`
 	PFXX           string `xml:"pfx_x,attr"`
 	PFXZ           string `xml:"pfx_z,attr"`
+	BreakY         string `xml:"break_y,attr"`
+	BreakAngle     string `xml:"break_angle,attr"`
+	BreakLength    string `xml:"break_length,attr"`
 	Type           string `xml:"type,attr"`
-	TypeConfidence string `xml:"type_confidence"`
+	PitchType      string `xml:"pitch_type,attr"`
+	TypeConfidence string `xml:"type_confidence,attr"`
 	Zone           string `xml:"zone,attr"`
+	Nasty          string `xml:"nasty,attr"`
+	SpinDir        string `xml:"spin_dir,attr"`
+	SpinRate       string `xml:"spin_rate,attr"`
 	SZTop          string `xml:"sz_top,attr"`
 	SZBot          string `xml:"sz_bot,attr"`
 }
@@ -389,27 +519,56 @@ type PitchXML struct {
 AtBatXML represents an at bat
 */
 type AtBatXML struct {
-	PlayNumber       int        `xml:"num,attr"`
-	PlayGUID         string     `xml:"play_guid,attr"`
-	AwayTeamRuns     int        `xml:"away_team_runs,attr"`
-	HomeTeamRuns     int        `xml:"home_team_runs,attr"`
-	Balls            int        `xml:"b,attr"`
-	Strikes          int        `xml:"s,attr"`
-	Outs             int        `xml:"o,attr"`
-	BatterID         int        `xml:"batter,attr"`
-	BatterHeight     string     `xml:"b_height,attr"`
-	BatterSide       string     `xml:"stand,attr"`
-	PitcherID        int        `xml:"pitcher,attr"`
-	PitcherSide      string     `xml:"p_throws,attr"`
-	EnglishDesc      string     `xml:"des,attr"`
-	SpanishDesc      string     `xml:"des_es,attr"`
-	EnglishEventDesc string     `xml:"event,attr"`
-	SpanishEventDesc string     `xml:"event_es,attr"`
-	EventNumber      int        `xml:"event_num,attr"`
-	StartTFSZulu     string     `xml:"start_tfs_zulu,attr"`
-	EndTFSZulu       string     `xml:"end_tfs_zulu,attr"`
-	StartTFS         string     `xml:"start_tfs,attr"`
-	Pitches          []PitchXML `xml:"pitch"`
+	PlayNumber       int          `xml:"num,attr"`
+	PlayGUID         string       `xml:"play_guid,attr"`
+	AwayTeamRuns     int          `xml:"away_team_runs,attr"`
+	HomeTeamRuns     int          `xml:"home_team_runs,attr"`
+	Balls            int          `xml:"b,attr"`
+	Strikes          int          `xml:"s,attr"`
+	Outs             int          `xml:"o,attr"`
+	BatterID         int          `xml:"batter,attr"`
+	BatterHeight     string       `xml:"b_height,attr"`
+	BatterSide       string       `xml:"stand,attr"`
+	PitcherID        int          `xml:"pitcher,attr"`
+	PitcherSide      string       `xml:"p_throws,attr"`
+	EnglishDesc      string       `xml:"des,attr"`
+	SpanishDesc      string       `xml:"des_es,attr"`
+	EnglishEventDesc string       `xml:"event,attr"`
+	SpanishEventDesc string       `xml:"event_es,attr"`
+	EventNumber      int          `xml:"event_num,attr"`
+	StartTFSZulu     string       `xml:"start_tfs_zulu,attr"`
+	EndTFSZulu       string       `xml:"end_tfs_zulu,attr"`
+	StartTFS         string       `xml:"start_tfs,attr"`
+	Pitches          []PitchXML   `xml:"pitch"`
+	Runners          []RunnerXML  `xml:"runner"`
+	Pickoffs         []PickoffXML `xml:"po"`
+}
+
+/*
+RunnerXML represents a runner who moved, or was put out, during an at bat.
+	The start and end bases are 1B, 2B or 3B, or empty for home plate; score,
+	rbi and earned are T when they apply.
+*/
+type RunnerXML struct {
+	ID       int    `xml:"id,attr"`
+	Start    string `xml:"start,attr"`
+	End      string `xml:"end,attr"`
+	Event    string `xml:"event,attr"`
+	EventNum string `xml:"event_num,attr"`
+	Score    string `xml:"score,attr"`
+	RBI      string `xml:"rbi,attr"`
+	Earned   string `xml:"earned,attr"`
+}
+
+/*
+PickoffXML represents a pickoff attempt during an at bat, such as
+	des="Pickoff Attempt 1B".  Catcher is Y when the catcher made the throw.
+*/
+type PickoffXML struct {
+	Des      string `xml:"des,attr"`
+	EventNum string `xml:"event_num,attr"`
+	PlayGUID string `xml:"play_guid,attr"`
+	Catcher  string `xml:"catcher,attr"`
 }
 
 /*
@@ -443,12 +602,61 @@ type GameXML struct {
 }
 
 /*
-LoadGamedayXML takes an XML file and bulk loads it into the database
+atBatContext is an at bat and where it happened in the game
+*/
+type atBatContext struct {
+	inning InningXML
+	topBot string
+	// number counts the at bats of the game from one, in the order they
+	//	were batted
+	number int
+	// outs is the number of outs when the at bat started
+	outs  int
+	atBat AtBatXML
+}
+
+/*
+atBats returns every at bat of the game in order.  Each of the tables loaded
+	from an inning_all file numbers the at bats this way, so that they can
+	be joined.
+*/
+func (g *GameXML) atBats() []atBatContext {
+	var atBats []atBatContext
+	for _, inning := range g.Innings {
+		halves := []struct {
+			topBot string
+			half   HalfInningXML
+		}{{"Top", inning.Top}, {"Bot", inning.Bottom}}
+		for _, h := range halves {
+			outs := 0
+			for _, atbat := range h.half.AtBats {
+				atBats = append(atBats, atBatContext{
+					inning: inning,
+					topBot: h.topBot,
+					number: len(atBats) + 1,
+					outs:   outs,
+					atBat:  atbat,
+				})
+				outs = atbat.Outs
+			}
+		}
+	}
+	return atBats
+}
+
+/*
+LoadGamedayXML takes an XML file, plain or compressed, and bulk loads it into
+	the database: the times of each pitch into mlb_gameday, the PITCHf/x
+	data into mlb_pitchfx, the runners into mlb_runner and the pickoff
+	attempts into mlb_pickoff.  Like LoadSavantCSV, the file is loaded in a
+	single transaction.  The rows that an earlier load of the same game left
+	in the four tables are deleted first, so loading a file again replaces
+	them.  The times that the older files don't have are loaded as nulls.
 */
-func (bdb *BaseballDB) LoadGamedayXML(f string) error {
-	fp, err := os.Open(f)
+func (bdb *BaseballDB) LoadGamedayXML(ctx context.Context, f string) error {
+	fp, err := util.OpenFile(f)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer fp.Close()
 
@@ -465,12 +673,24 @@ func (bdb *BaseballDB) LoadGamedayXML(f string) error {
 		return err
 	}
 
-	txn, err := bdb.dbConn.Begin()
+	txn, err := bdb.dbConn.BeginTx(ctx, nil)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	defer txn.Rollback()
+
+	gameDate := fmt.Sprintf("%s-%s-%s", gY, gM, gD)
+	game := pitchGame{
+		file:       f,
+		gameDate:   gameDate,
+		gameNumber: gN,
+	}
+	err = deleteGame(ctx, txn, game, &g)
+	if err != nil {
+		return err
 	}
 
-	stmt, err := txn.Prepare(pq.CopyIn("mlb_gameday",
+	stmt, err := txn.PrepareContext(ctx, bdb.dbConn.CopyIn("mlb_gameday",
 		"game_date", "away_team", "home_team", "game_number",
 		"inning", "at_bat_number", "at_bat_start_tfs",
 		"at_bat_start_tfs_zulu", "at_bat_end_tfs_zulu",
@@ -480,37 +700,23 @@ func (bdb *BaseballDB) LoadGamedayXML(f string) error {
 		return err
 	}
 
-	atBatNum := 0
-	for _, inning := range g.Innings {
-		for _, atbat := range inning.Top.AtBats {
-			atBatNum++
-			for pitchnum, pitch := range atbat.Pitches {
-				gameDate := fmt.Sprintf("%s-%s-%s", gY, gM, gD)
-				_, err = stmt.Exec(gameDate, strings.ToUpper(inning.AwayTeam),
-					strings.ToUpper(inning.HomeTeam), gN, inning.Num,
-					atBatNum, atbat.StartTFS, atbat.StartTFSZulu, atbat.EndTFSZulu,
-					pitchnum+1, pitch.SVID, pitch.TFS, pitch.TFSZulu)
-				if err != nil {
-					return err
-				}
-			}
-		}
-		for _, atbat := range inning.Bottom.AtBats {
-			atBatNum++
-			for pitchnum, pitch := range atbat.Pitches {
-				gameDate := fmt.Sprintf("%s-%s-%s", gY, gM, gD)
-				_, err = stmt.Exec(gameDate, strings.ToUpper(inning.AwayTeam),
-					strings.ToUpper(inning.HomeTeam), gN, inning.Num,
-					atBatNum, atbat.StartTFS, atbat.StartTFSZulu, atbat.EndTFSZulu,
-					pitchnum+1, pitch.SVID, pitch.TFS, pitch.TFSZulu)
-				if err != nil {
-					return err
-				}
+	rows := 0
+	for _, ab := range g.atBats() {
+		atbat := ab.atBat
+		for pitchnum, pitch := range atbat.Pitches {
+			_, err = stmt.ExecContext(ctx, gameDate, strings.ToUpper(ab.inning.AwayTeam),
+				strings.ToUpper(ab.inning.HomeTeam), gN, ab.inning.Num,
+				ab.number, nullString(atbat.StartTFS), nullString(atbat.StartTFSZulu),
+				nullString(atbat.EndTFSZulu), pitchnum+1, nullString(pitch.SVID),
+				nullString(pitch.TFS), nullString(pitch.TFSZulu))
+			if err != nil {
+				return err
 			}
+			rows++
 		}
 	}
 
-	_, err = stmt.Exec()
+	err = bdb.dbConn.FinishCopy(ctx, stmt)
 	if err != nil {
 		return err
 	}
@@ -520,10 +726,52 @@ func (bdb *BaseballDB) LoadGamedayXML(f string) error {
 		return err
 	}
 
+	// Only one bulk load can be in progress at a time, so the other tables
+	//	are loaded one after another once mlb_gameday is finished
+	pitches, err := bdb.loadPitchFX(ctx, txn, game, &g)
+	if err != nil {
+		return err
+	}
+	runners, err := bdb.loadRunners(ctx, txn, game, &g)
+	if err != nil {
+		return err
+	}
+	pickoffs, err := bdb.loadPickoffs(ctx, txn, game, &g)
+	if err != nil {
+		return err
+	}
+
 	err = txn.Commit()
 	if err != nil {
 		return err
 	}
+	results := summary.FromContext(ctx)
+	results.Add(summary.RowsLoaded("mlb_gameday"), int64(rows))
+	results.Add(summary.RowsLoaded("mlb_pitchfx"), int64(pitches))
+	results.Add(summary.RowsLoaded("mlb_runner"), int64(runners))
+	results.Add(summary.RowsLoaded("mlb_pickoff"), int64(pickoffs))
+
+	return nil
+}
 
+/*
+deleteGame removes the rows of a game from the four tables loaded from its
+	inning_all file as part of the transaction that loads it again.  The
+	teams of the game are those of its first inning.
+*/
+func deleteGame(ctx context.Context, txn *sql.Tx, game pitchGame, g *GameXML) error {
+	if len(g.Innings) == 0 {
+		return nil
+	}
+	awayTeam := strings.ToUpper(g.Innings[0].AwayTeam)
+	homeTeam := strings.ToUpper(g.Innings[0].HomeTeam)
+	for _, table := range []string{"mlb_gameday", "mlb_pitchfx", "mlb_runner", "mlb_pickoff"} {
+		_, err := txn.ExecContext(ctx, fmt.Sprintf(`delete from %s
+			where game_date = $1 and away_team = $2 and home_team = $3 and game_number = $4;`, table),
+			game.gameDate, awayTeam, homeTeam, game.gameNumber)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
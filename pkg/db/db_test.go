@@ -0,0 +1,94 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/records"
+)
+
+func TestSyncGames(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	bbdb := BaseballDB{}
+	if err := bbdb.Connect("sqlite:" + filepath.Join(dir, "baseball.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer bbdb.Close()
+
+	// 00:30 on June 11 in New York, which a fixed offset of -5 hours would
+	//	put on June 10
+	games := []records.GameRecord{
+		{EffectiveDate: time.Date(2019, 6, 10, 23, 10, 0, 0, time.UTC), ID: 565000, GameType: "R"},
+		{EffectiveDate: time.Date(2019, 6, 11, 4, 30, 0, 0, time.UTC), ID: 565012, GameType: "R"},
+	}
+	for _, gR := range games {
+		if err := gR.CreateTable(ctx, bbdb.dbConn); err != nil {
+			t.Fatal(err)
+		}
+		if err := gR.UpdateRecord(ctx, bbdb.dbConn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := records.GameStatusRecord{EffectiveDate: games[0].EffectiveDate, ID: 565000, Status: "Final"}
+	if err := status.CreateTable(ctx, bbdb.dbConn); err != nil {
+		t.Fatal(err)
+	}
+	if err := status.UpdateRecord(ctx, bbdb.dbConn); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := bbdb.ScheduledGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule["20190610"]) != 1 || schedule["20190610"][0].Played() == false {
+		t.Errorf("Expected the finished game on the 10th, got %+v", schedule["20190610"])
+	}
+	if len(schedule["20190611"]) != 1 || schedule["20190611"][0].ID != 565012 || schedule["20190611"][0].Played() {
+		t.Errorf("Expected the unfinished game on the 11th, got %+v", schedule["20190611"])
+	}
+
+	data, err := fs.ReadFile(fixtures.Default(), "components/game/mlb/year_2019/month_06/day_11/gid_2019_06_11_nyamlb_bosmlb_1/inning/inning_all.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gid_2019_06_11_nyamlb_bosmlb_1_inning_inning_all.xml")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = bbdb.ConfirmGamedayMaster(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = bbdb.LoadGamedayXML(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := bbdb.LoadedGames(ctx, "mlb_gameday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || len(loaded["20190611"]) != 1 || loaded["20190611"]["nya_bos_1"] == false {
+		t.Errorf("Expected nya_bos_1 on the 11th, got %v", loaded)
+	}
+}
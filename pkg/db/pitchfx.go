@@ -0,0 +1,237 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+/*
+ConfirmPitchFXTable makes sure that the table of PITCHf/x pitches is present.
+	If not, create it.  There is a row for every pitch in an inning_all
+	file, with the tracking data and the situation it was thrown in, which
+	covers the 2008 to 2014 seasons that Statcast does not.
+*/
+func (bdb *BaseballDB) ConfirmPitchFXTable(ctx context.Context) error {
+	return bdb.dbConn.CreateTable(ctx, `create table if not exists mlb_pitchfx (
+		game_date date,
+		away_team text,
+		home_team text,
+		game_number integer,
+		inning integer,
+		inning_topbot text,
+		at_bat_number integer,
+		pitch_number integer,
+		batter integer,
+		pitcher integer,
+		stand text,
+		p_throws text,
+		event text,
+		des text,
+		balls integer,
+		strikes integer,
+		outs_when_up integer,
+		pitch_id integer,
+		pitch_des text,
+		code text,
+		type text,
+		sv_id text,
+		play_guid text,
+		pitch_tfs_zulu timestamp with time zone,
+		x double precision,
+		y double precision,
+		start_speed double precision,
+		end_speed double precision,
+		sz_top double precision,
+		sz_bot double precision,
+		pfx_x double precision,
+		pfx_z double precision,
+		px double precision,
+		pz double precision,
+		x0 double precision,
+		y0 double precision,
+		z0 double precision,
+		vx0 double precision,
+		vy0 double precision,
+		vz0 double precision,
+		ax double precision,
+		ay double precision,
+		az double precision,
+		break_y double precision,
+		break_angle double precision,
+		break_length double precision,
+		pitch_type text,
+		type_confidence double precision,
+		zone integer,
+		nasty integer,
+		spin_dir double precision,
+		spin_rate double precision,
+		primary key (game_date, away_team, home_team, game_number, at_bat_number, pitch_number));
+	`)
+}
+
+/*
+DropPitchFXTable gets rid of the table if it exists
+*/
+func (bdb *BaseballDB) DropPitchFXTable() error {
+	_, err := bdb.dbConn.Exec("drop table if exists mlb_pitchfx;")
+	return err
+}
+
+/*
+pitchGame identifies the game that the rows loaded from an inning_all file
+	belong to
+*/
+type pitchGame struct {
+	file       string
+	gameDate   string
+	gameNumber string
+}
+
+/*
+loadPitchFX copies every pitch of a game into mlb_pitchfx as part of the
+	transaction that loads the file.  At bats are numbered the same way as in
+	mlb_gameday (see GameXML.atBats), so the two tables can be joined on the
+	game, at_bat_number and pitch_number.  The number of rows is returned.
+*/
+func (bdb *BaseballDB) loadPitchFX(ctx context.Context, txn *sql.Tx, game pitchGame, g *GameXML) (int, error) {
+	stmt, err := txn.PrepareContext(ctx, bdb.dbConn.CopyIn("mlb_pitchfx",
+		"game_date", "away_team", "home_team", "game_number",
+		"inning", "inning_topbot", "at_bat_number", "pitch_number",
+		"batter", "pitcher", "stand", "p_throws", "event", "des",
+		"balls", "strikes", "outs_when_up",
+		"pitch_id", "pitch_des", "code", "type", "sv_id", "play_guid",
+		"pitch_tfs_zulu", "x", "y", "start_speed", "end_speed",
+		"sz_top", "sz_bot", "pfx_x", "pfx_z", "px", "pz",
+		"x0", "y0", "z0", "vx0", "vy0", "vz0", "ax", "ay", "az",
+		"break_y", "break_angle", "break_length", "pitch_type",
+		"type_confidence", "zone", "nasty", "spin_dir", "spin_rate"))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	f := func(column, value string) float64 {
+		return pitchFloat(game.file, column, value)
+	}
+	i := func(column, value string) int {
+		return pitchInt(game.file, column, value)
+	}
+
+	rows := 0
+	for _, ab := range g.atBats() {
+		atbat := ab.atBat
+		balls, strikes := 0, 0
+		for pitchnum, p := range atbat.Pitches {
+			_, err = stmt.ExecContext(ctx, game.gameDate, strings.ToUpper(ab.inning.AwayTeam),
+				strings.ToUpper(ab.inning.HomeTeam), game.gameNumber, ab.inning.Num, ab.topBot,
+				ab.number, pitchnum+1, atbat.BatterID, atbat.PitcherID,
+				atbat.BatterSide, atbat.PitcherSide, atbat.EnglishEventDesc,
+				atbat.EnglishDesc, balls, strikes, ab.outs,
+				i("id", p.ID), p.Des, p.Code, p.Type, nullString(p.SVID),
+				nullString(p.PlayGUID), nullString(p.TFSZulu),
+				f("x", p.X), f("y", p.Y), f("start_speed", p.StartSpeed),
+				f("end_speed", p.EndSpeed), f("sz_top", p.SZTop),
+				f("sz_bot", p.SZBot), f("pfx_x", p.PFXX), f("pfx_z", p.PFXZ),
+				f("px", p.PX), f("pz", p.PZ), f("x0", p.X0), f("y0", p.Y0),
+				f("z0", p.Z0), f("vx0", p.VX0), f("vy0", p.VY0),
+				f("vz0", p.VZ0), f("ax", p.AX0), f("ay", p.AY0),
+				f("az", p.AZ0), f("break_y", p.BreakY),
+				f("break_angle", p.BreakAngle), f("break_length", p.BreakLength),
+				p.PitchType, f("type_confidence", p.TypeConfidence),
+				i("zone", p.Zone), i("nasty", p.Nasty),
+				f("spin_dir", p.SpinDir), f("spin_rate", p.SpinRate))
+			if err != nil {
+				return 0, err
+			}
+			rows++
+			balls, strikes = nextCount(balls, strikes, p)
+		}
+	}
+
+	err = bdb.dbConn.FinishCopy(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+/*
+nextCount returns the count after a pitch.  A foul with two strikes leaves the
+	count as it was, unless it is a foul tip or a foul bunt, which are strike
+	three.
+*/
+func nextCount(balls, strikes int, p PitchXML) (int, int) {
+	switch p.Type {
+	case "B":
+		balls++
+	case "S":
+		foul := strings.HasPrefix(p.Des, "Foul") &&
+			strings.HasPrefix(p.Des, "Foul Tip") == false &&
+			strings.HasPrefix(p.Des, "Foul Bunt") == false
+		if strikes < 2 || foul == false {
+			strikes++
+		}
+	}
+	return balls, strikes
+}
+
+/*
+pitchFloat parses a PITCHf/x measurement.  Like the Savant columns, a missing
+	value is stored as -88 and one that can't be parsed as -99.
+*/
+func pitchFloat(file, column, value string) float64 {
+	if len(value) == 0 || value == "placeholder" {
+		return -88.0
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		slog.Warn("Unable to parse a pitch attribute", "file", file, "attribute", column, "err", err)
+		return -99.0
+	}
+	return v
+}
+
+/*
+pitchInt parses a whole number attribute of a pitch (see pitchFloat)
+*/
+func pitchInt(file, column, value string) int {
+	if len(value) == 0 {
+		return -88
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Unable to parse a pitch attribute", "file", file, "attribute", column, "err", err)
+		return -99
+	}
+	return v
+}
+
+/*
+nullString stores an attribute that is missing from the older files, which
+	would not be a valid time or number, as null
+*/
+func nullString(value string) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return value
+}
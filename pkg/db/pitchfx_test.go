@@ -0,0 +1,117 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+)
+
+func TestLoadPitchFX(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	data, err := fs.ReadFile(fixtures.Default(), "components/game/mlb/year_2019/month_06/day_11/gid_2019_06_11_nyamlb_bosmlb_1/inning/inning_all.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gid_2019_06_11_nyamlb_bosmlb_1_inning_inning_all.xml")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bbdb := BaseballDB{}
+	if err = bbdb.Connect("sqlite:" + filepath.Join(dir, "baseball.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer bbdb.Close()
+	if err = bbdb.ConfirmGamedayMaster(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = bbdb.LoadGamedayXML(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+
+	var gameday, pitchfx int
+	bbdb.dbConn.QueryRowContext(ctx, "select count(*) from mlb_gameday;").Scan(&gameday)
+	bbdb.dbConn.QueryRowContext(ctx, "select count(*) from mlb_pitchfx;").Scan(&pitchfx)
+	if gameday == 0 || gameday != pitchfx {
+		t.Errorf("Expected a pitchfx row for each of the %d gameday rows, got %d", gameday, pitchfx)
+	}
+
+	var pitchTest = []struct {
+		AtBat            int
+		Pitch            int
+		ExpectedTopBot   string
+		ExpectedBalls    int
+		ExpectedStrikes  int
+		ExpectedOuts     int
+		ExpectedSpeed    float64
+		ExpectedAX       float64
+		ExpectedPitch    string
+		ExpectedBatter   int
+		ExpectedPitchDes string
+	}{
+		{1, 1, "Top", 0, 0, 0, 95.1, -7.3, "FF", 592450, "Ball"},
+		{1, 4, "Top", 1, 2, 0, 96.2, -7.3, "FF", 592450, "Swinging Strike"},
+		{2, 1, "Top", 0, 0, 1, 87.9, -7.3, "CH", 519317, "Ball"},
+	}
+	for _, ex := range pitchTest {
+		var topBot, pitchType, des string
+		var balls, strikes, outs, batter int
+		var speed, ax float64
+		err = bbdb.dbConn.QueryRowContext(ctx, `select inning_topbot, balls, strikes, outs_when_up, start_speed, ax, pitch_type, batter, pitch_des
+			from mlb_pitchfx where at_bat_number = $1 and pitch_number = $2;`, ex.AtBat, ex.Pitch).Scan(
+			&topBot, &balls, &strikes, &outs, &speed, &ax, &pitchType, &batter, &des)
+		if err != nil {
+			t.Errorf("At bat %d pitch %d: %s", ex.AtBat, ex.Pitch, err)
+			continue
+		}
+		if topBot != ex.ExpectedTopBot || balls != ex.ExpectedBalls || strikes != ex.ExpectedStrikes ||
+			outs != ex.ExpectedOuts || batter != ex.ExpectedBatter {
+			t.Errorf("At bat %d pitch %d: expected %s %d-%d with %d out to %d, got %s %d-%d with %d out to %d",
+				ex.AtBat, ex.Pitch, ex.ExpectedTopBot, ex.ExpectedBalls, ex.ExpectedStrikes, ex.ExpectedOuts, ex.ExpectedBatter,
+				topBot, balls, strikes, outs, batter)
+		}
+		if speed != ex.ExpectedSpeed || ax != ex.ExpectedAX || pitchType != ex.ExpectedPitch || des != ex.ExpectedPitchDes {
+			t.Errorf("At bat %d pitch %d: expected a %s at %.1f with ax %.1f (%s), got a %s at %.1f with ax %.1f (%s)",
+				ex.AtBat, ex.Pitch, ex.ExpectedPitch, ex.ExpectedSpeed, ex.ExpectedAX, ex.ExpectedPitchDes, pitchType, speed, ax, des)
+		}
+	}
+
+	var countTest = []struct {
+		Des             string
+		Type            string
+		Strikes         int
+		ExpectedStrikes int
+	}{
+		{"Foul", "S", 1, 2},
+		{"Foul", "S", 2, 2},
+		{"Foul Tip", "S", 2, 3},
+		{"Foul Bunt", "S", 2, 3},
+		{"Called Strike", "S", 2, 3},
+	}
+	for _, ex := range countTest {
+		if _, strikes := nextCount(0, ex.Strikes, PitchXML{Des: ex.Des, Type: ex.Type}); strikes != ex.ExpectedStrikes {
+			t.Errorf("%s with %d strikes: expected %d strikes after, got %d", ex.Des, ex.Strikes, ex.ExpectedStrikes, strikes)
+		}
+	}
+}
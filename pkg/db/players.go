@@ -0,0 +1,133 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/records"
+)
+
+/*
+PlayerLookup turns the MLBAM IDs that identify players in mlb_savant and
+	mlb_gameday (batter, pitcher, fielder_2, catcher_id and so on) into
+	players, so that reports can print names instead of IDs
+*/
+type PlayerLookup struct {
+	players map[int64]records.PlayerRecord
+}
+
+/*
+Players builds a PlayerLookup from the PlayerRecord table that the pipeline
+	fills in from the gameday rosters.  Each player is the version that was
+	in effect on asOf, or the latest version when asOf is zero.  Pitchers
+	who aren't in the table get the name Savant gives them in player_name.
+	Both tables are created if they aren't there yet, so an empty lookup
+	just means that nothing has been loaded.
+*/
+func (bdb *BaseballDB) Players(ctx context.Context, asOf time.Time) (*PlayerLookup, error) {
+	pl := &PlayerLookup{players: make(map[int64]records.PlayerRecord)}
+
+	err := (&records.PlayerRecord{}).CreateTable(ctx, bdb.dbConn)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := bdb.dbConn.QueryContext(ctx, `select effectiveDate, id, firstname, lastname, boxname, number,
+		birthdate, bats, throws, position, teamid from PlayerRecord;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		pR := records.PlayerRecord{RecordName: "PlayerRecord"}
+		err = rows.Scan(&pR.EffectiveDate, &pR.ID, &pR.FirstName, &pR.LastName, &pR.BoxName, &pR.Number,
+			&pR.BirthDate, &pR.Bats, &pR.Throws, &pR.Position, &pR.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if asOf.IsZero() == false && pR.EffectiveDate.After(asOf) {
+			continue
+		}
+		existing, ok := pl.players[pR.ID]
+		if ok == false || pR.EffectiveDate.After(existing.EffectiveDate) {
+			pl.players[pR.ID] = pR
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = bdb.ConfirmSavantMaster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	savantRows, err := bdb.dbConn.QueryContext(ctx, `select pitcher, max(player_name) from mlb_savant
+		where player_name is not null and player_name <> '' group by pitcher;`)
+	if err != nil {
+		return nil, err
+	}
+	defer savantRows.Close()
+	for savantRows.Next() {
+		var id int64
+		var name string
+		err = savantRows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pl.players[id]; ok {
+			continue
+		}
+		pl.players[id] = savantPlayer(id, name)
+	}
+	return pl, savantRows.Err()
+}
+
+/*
+savantPlayer makes a player out of the player_name of a Savant pitch, which
+	is written "Last, First"
+*/
+func savantPlayer(id int64, name string) records.PlayerRecord {
+	pR := records.PlayerRecord{RecordName: "PlayerRecord", ID: id, LastName: name, Position: "P"}
+	if last, first, ok := strings.Cut(name, ", "); ok {
+		pR.FirstName = first
+		pR.LastName = last
+	}
+	return pR
+}
+
+/*
+Player returns the player with an ID
+*/
+func (pl *PlayerLookup) Player(id int64) (records.PlayerRecord, bool) {
+	pR, ok := pl.players[id]
+	return pR, ok
+}
+
+/*
+Name returns the first and last name of the player with an ID, or the ID
+	itself for a player that isn't known
+*/
+func (pl *PlayerLookup) Name(id int64) string {
+	pR, ok := pl.players[id]
+	if ok == false {
+		return strconv.FormatInt(id, 10)
+	}
+	return pR.FullName()
+}
@@ -0,0 +1,117 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/records"
+)
+
+func TestPlayers(t *testing.T) {
+	ctx := context.Background()
+	bbdb := BaseballDB{}
+	if err := bbdb.Connect("sqlite:" + filepath.Join(t.TempDir(), "baseball.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer bbdb.Close()
+
+	june10 := time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)
+	june11 := june10.AddDate(0, 0, 1)
+	july31 := time.Date(2019, 7, 31, 0, 0, 0, 0, time.UTC)
+	august15 := time.Date(2019, 8, 15, 0, 0, 0, 0, time.UTC)
+	players := []records.PlayerRecord{
+		{EffectiveDate: june11, ID: 592450, FirstName: "Aaron", LastName: "Judge", BirthDate: "1992-04-26", Position: "RF", TeamID: 147},
+		// The same version seen earlier moves the start of the version back
+		{EffectiveDate: june10, ID: 592450, FirstName: "Aaron", LastName: "Judge", BirthDate: "1992-04-26", Position: "RF", TeamID: 147},
+		{EffectiveDate: june10, ID: 605141, FirstName: "Mookie", LastName: "Betts", Position: "RF", TeamID: 111},
+		{EffectiveDate: july31, ID: 605141, FirstName: "Mookie", LastName: "Betts", Position: "RF", TeamID: 119},
+		// Going back to a former team is a new version, not the old one
+		{EffectiveDate: june10, ID: 547973, FirstName: "Aroldis", LastName: "Chapman", Position: "P", TeamID: 147},
+		{EffectiveDate: july31, ID: 547973, FirstName: "Aroldis", LastName: "Chapman", Position: "P", TeamID: 112},
+		{EffectiveDate: august15, ID: 547973, FirstName: "Aroldis", LastName: "Chapman", Position: "P", TeamID: 147},
+		{EffectiveDate: august15.AddDate(0, 0, 1), ID: 547973, FirstName: "Aroldis", LastName: "Chapman", Position: "P", TeamID: 147},
+		// A 404 on the player's file leaves the birth date empty, which isn't a new version
+		{EffectiveDate: june10, ID: 593428, FirstName: "Xander", LastName: "Bogaerts", Position: "SS", TeamID: 111},
+		{EffectiveDate: june11, ID: 593428, FirstName: "Xander", LastName: "Bogaerts", BirthDate: "1992-10-01", Position: "SS", TeamID: 111},
+		{EffectiveDate: july31, ID: 593428, FirstName: "Xander", LastName: "Bogaerts", Position: "SS", TeamID: 111},
+		{EffectiveDate: august15, ID: 593428, FirstName: "Xander", LastName: "Bogaerts", Position: "3B", TeamID: 111},
+	}
+	if err := players[0].CreateTable(ctx, bbdb.dbConn); err != nil {
+		t.Fatal(err)
+	}
+	for _, pR := range players {
+		if err := pR.UpdateRecord(ctx, bbdb.dbConn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bbdb.ConfirmSavantMaster(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, pitch := range []struct {
+		Pitcher int64
+		Name    string
+	}{{543037, "Cole, Gerrit"}, {592450, "Judge, Aaron"}} {
+		_, err := bbdb.dbConn.ExecContext(ctx, "insert into mlb_savant (pitcher, player_name) values ($1, $2);", pitch.Pitcher, pitch.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var playersTest = []struct {
+		AsOf   time.Time
+		ID     int64
+		Name   string
+		TeamID int64
+	}{
+		{time.Time{}, 592450, "Aaron Judge", 147},
+		{june10, 592450, "Aaron Judge", 147},
+		{june10, 605141, "Mookie Betts", 111},
+		{time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC), 605141, "Mookie Betts", 119},
+		{time.Time{}, 543037, "Gerrit Cole", 0},
+		{time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC), 547973, "Aroldis Chapman", 112},
+		{time.Time{}, 547973, "Aroldis Chapman", 147},
+		{time.Time{}, 1, "1", 0},
+	}
+	for _, ex := range playersTest {
+		lookup, err := bbdb.Players(ctx, ex.AsOf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := lookup.Name(ex.ID); name != ex.Name {
+			t.Errorf("Expected %s for %d as of %s, received %s", ex.Name, ex.ID, ex.AsOf, name)
+		}
+		if pR, _ := lookup.Player(ex.ID); pR.TeamID != ex.TeamID {
+			t.Errorf("Expected team %d for %d as of %s, received %d", ex.TeamID, ex.ID, ex.AsOf, pR.TeamID)
+		}
+	}
+
+	var count int
+	bbdb.dbConn.QueryRowContext(ctx, "select count(*) from PlayerRecord;").Scan(&count)
+	if count != 8 {
+		t.Errorf("Expected 8 versions of players, found %d", count)
+	}
+
+	// The birth date is carried to every version once it has been seen
+	bbdb.dbConn.QueryRowContext(ctx, "select count(*) from PlayerRecord where id = 593428 and birthdate = '1992-10-01';").Scan(&count)
+	if count != 2 {
+		t.Errorf("Expected the birth date on 2 versions of Bogaerts, found %d", count)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+/*
+ConfirmRunnerTables makes sure that the tables of runner movements and
+	pickoff attempts are present.  If not, create them.  A row is keyed by
+	the game, the at bat and its place in the at bat (runner_number or
+	pickoff_number), which every row has, unlike event_num.
+*/
+func (bdb *BaseballDB) ConfirmRunnerTables(ctx context.Context) error {
+	err := bdb.dbConn.CreateTable(ctx, `create table if not exists mlb_runner (
+		game_date date,
+		away_team text,
+		home_team text,
+		game_number integer,
+		inning integer,
+		inning_topbot text,
+		at_bat_number integer,
+		runner_number integer,
+		runner integer,
+		start_base text,
+		end_base text,
+		event text,
+		event_num integer,
+		score boolean,
+		rbi boolean,
+		earned boolean,
+		primary key (game_date, away_team, home_team, game_number, at_bat_number, runner_number));
+	`)
+	if err != nil {
+		return err
+	}
+	return bdb.dbConn.CreateTable(ctx, `create table if not exists mlb_pickoff (
+		game_date date,
+		away_team text,
+		home_team text,
+		game_number integer,
+		inning integer,
+		inning_topbot text,
+		at_bat_number integer,
+		pickoff_number integer,
+		pitcher integer,
+		batter integer,
+		base text,
+		des text,
+		event_num integer,
+		catcher boolean,
+		play_guid text,
+		primary key (game_date, away_team, home_team, game_number, at_bat_number, pickoff_number));
+	`)
+}
+
+/*
+DropRunnerTables gets rid of the tables if they exist
+*/
+func (bdb *BaseballDB) DropRunnerTables() error {
+	_, err := bdb.dbConn.Exec("drop table if exists mlb_runner;")
+	if err != nil {
+		return err
+	}
+	_, err = bdb.dbConn.Exec("drop table if exists mlb_pickoff;")
+	return err
+}
+
+/*
+loadRunners copies the runner movements of a game into mlb_runner, one row
+	for every runner who moved or was put out in an at bat, as part of the
+	transaction that loads the file.  The runners of an at bat are numbered
+	in the order of the file.  A runner who scores has an end base of home.
+	The number of rows is returned.
+*/
+func (bdb *BaseballDB) loadRunners(ctx context.Context, txn *sql.Tx, game pitchGame, g *GameXML) (int, error) {
+	stmt, err := txn.PrepareContext(ctx, bdb.dbConn.CopyIn("mlb_runner",
+		"game_date", "away_team", "home_team", "game_number",
+		"inning", "inning_topbot", "at_bat_number", "runner_number", "runner",
+		"start_base", "end_base", "event", "event_num",
+		"score", "rbi", "earned"))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	rows := 0
+	for _, ab := range g.atBats() {
+		for runnerNum, runner := range ab.atBat.Runners {
+			end := runner.End
+			if len(end) == 0 && runner.Score == "T" {
+				end = "home"
+			}
+			_, err = stmt.ExecContext(ctx, game.gameDate, strings.ToUpper(ab.inning.AwayTeam),
+				strings.ToUpper(ab.inning.HomeTeam), game.gameNumber, ab.inning.Num, ab.topBot,
+				ab.number, runnerNum+1, runner.ID, nullString(runner.Start), nullString(end),
+				runner.Event, pitchInt(game.file, "event_num", runner.EventNum),
+				runner.Score == "T", runner.RBI == "T", runner.Earned == "T")
+			if err != nil {
+				return 0, err
+			}
+			rows++
+		}
+	}
+
+	err = bdb.dbConn.FinishCopy(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+/*
+loadPickoffs copies the pickoff attempts of a game into mlb_pickoff as part of
+	the transaction that loads the file.  The pickoffs of an at bat are
+	numbered in the order of the file.  The base is taken from the end of
+	the description, such as Pickoff Attempt 2B.  The number of rows is
+	returned.
+*/
+func (bdb *BaseballDB) loadPickoffs(ctx context.Context, txn *sql.Tx, game pitchGame, g *GameXML) (int, error) {
+	stmt, err := txn.PrepareContext(ctx, bdb.dbConn.CopyIn("mlb_pickoff",
+		"game_date", "away_team", "home_team", "game_number",
+		"inning", "inning_topbot", "at_bat_number", "pickoff_number", "pitcher", "batter",
+		"base", "des", "event_num", "catcher", "play_guid"))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	rows := 0
+	for _, ab := range g.atBats() {
+		for pickoffNum, po := range ab.atBat.Pickoffs {
+			_, err = stmt.ExecContext(ctx, game.gameDate, strings.ToUpper(ab.inning.AwayTeam),
+				strings.ToUpper(ab.inning.HomeTeam), game.gameNumber, ab.inning.Num, ab.topBot,
+				ab.number, pickoffNum+1, ab.atBat.PitcherID, ab.atBat.BatterID, nullString(pickoffBase(po.Des)),
+				po.Des, pitchInt(game.file, "event_num", po.EventNum), po.Catcher == "Y",
+				nullString(po.PlayGUID))
+			if err != nil {
+				return 0, err
+			}
+			rows++
+		}
+	}
+
+	err = bdb.dbConn.FinishCopy(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+/*
+pickoffBase returns the base (1B, 2B or 3B) a pickoff was attempted at, or an
+	empty string if the description doesn't say
+*/
+func pickoffBase(des string) string {
+	fields := strings.Fields(des)
+	if len(fields) == 0 {
+		return ""
+	}
+	switch base := fields[len(fields)-1]; base {
+	case "1B", "2B", "3B":
+		return base
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/summary"
+)
+
+func TestLoadRunners(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	inningAll := `<game atBat="1" deck="2" hole="3" ind="F">
+	<inning num="1" away_team="nya" home_team="bos" next="N">
+		<top>
+			<atbat num="1" b="0" s="0" o="0" batter="592450" pitcher="519242" event="Single">
+				<pitch des="In play, no out" id="1" type="X"/>
+				<runner id="592450" start="" end="1B" event="Single" event_num="4"/>
+			</atbat>
+			<atbat num="2" b="1" s="1" o="0" batter="519317" pitcher="519242" event="Home Run">
+				<po des="Pickoff Attempt 1B" event_num="7" play_guid="00000000-0000-0000-0000-000000000007"/>
+				<pitch des="Ball" id="2" type="B"/>
+				<po des="Pickoff Attempt 2B" event_num="9" catcher="Y"/>
+				<pitch des="In play, run(s)" id="3" type="X"/>
+				<runner id="592450" start="1B" end="" event="Home Run" event_num="11" score="T" rbi="T" earned="T"/>
+				<runner id="519317" start="" end="" event="Home Run" event_num="11" score="T" rbi="T" earned="T"/>
+			</atbat>
+		</top>
+		<bottom>
+			<atbat num="3" b="0" s="0" o="1" batter="646240" pitcher="543037" event="Groundout">
+				<runner id="593428" start="" end="1B" event="Walk"/>
+				<po des="Pickoff Attempt 1B"/>
+				<runner id="593428" start="1B" end="2B" event="Stolen Base 2B"/>
+				<po des="Pickoff Attempt 2B"/>
+				<pitch des="In play, out(s)" id="4" type="X"/>
+				<runner id="646240" start="" end="" event="Groundout"/>
+			</atbat>
+		</bottom>
+	</inning>
+</game>`
+	path := filepath.Join(dir, "gid_2019_06_10_nyamlb_bosmlb_1_inning_inning_all.xml")
+	if err := os.WriteFile(path, []byte(inningAll), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bbdb := BaseballDB{}
+	if err := bbdb.Connect("sqlite:" + filepath.Join(dir, "baseball.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer bbdb.Close()
+	if err := bbdb.ConfirmGamedayMaster(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Loading the file again replaces the rows of the game
+	for i := 0; i < 2; i++ {
+		if err := bbdb.LoadGamedayXML(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var runnerTest = []struct {
+		Query    string
+		Expected int
+	}{
+		{"select count(*) from mlb_runner;", 6},
+		{"select count(*) from mlb_runner where at_bat_number = 2 and score and rbi and earned and end_base = 'home';", 2},
+		{"select count(*) from mlb_runner where runner = 592450 and start_base is null and end_base = '1B' and inning_topbot = 'Top';", 1},
+		{"select count(*) from mlb_pickoff;", 4},
+		{"select count(*) from mlb_pickoff where base = '1B' and pitcher = 519242 and batter = 519317 and catcher = false;", 1},
+		{"select count(*) from mlb_pickoff where base = '2B' and event_num = 9 and catcher and play_guid is null;", 1},
+		{"select count(*) from mlb_runner where at_bat_number = 3 and runner = 593428 and event_num = -88;", 2},
+		{"select count(*) from mlb_runner where at_bat_number = 3 and runner_number = 2 and end_base = '2B';", 1},
+		{"select count(*) from mlb_pickoff where at_bat_number = 3 and pickoff_number = 2 and base = '2B' and event_num = -88;", 1},
+		{"select count(*) from mlb_gameday;", 4},
+		{"select count(*) from mlb_pitchfx;", 4},
+	}
+	for _, ex := range runnerTest {
+		var count int
+		if err := bbdb.dbConn.QueryRowContext(ctx, ex.Query).Scan(&count); err != nil {
+			t.Errorf("%s: %s", ex.Query, err)
+			continue
+		}
+		if count != ex.Expected {
+			t.Errorf("%s: expected %d, got %d", ex.Query, ex.Expected, count)
+		}
+	}
+}
+
+func TestLoadRunnersFixture(t *testing.T) {
+	dir := t.TempDir()
+	data, err := fs.ReadFile(fixtures.Default(), "components/game/mlb/year_2019/month_06/day_11/gid_2019_06_11_nyamlb_bosmlb_1/inning/inning_all.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gid_2019_06_11_nyamlb_bosmlb_1_inning_inning_all.xml")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bbdb := BaseballDB{}
+	if err = bbdb.Connect("sqlite:" + filepath.Join(dir, "baseball.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer bbdb.Close()
+	if err = bbdb.ConfirmGamedayMaster(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var loadTest = []struct {
+		ExpectedRunners  int64
+		ExpectedPickoffs int64
+	}{
+		{1, 1},
+		{1, 1},
+	}
+	for i, ex := range loadTest {
+		results := summary.New("loadGameday")
+		if err = bbdb.LoadGamedayXML(summary.NewContext(context.Background(), results), path); err != nil {
+			t.Fatal(err)
+		}
+		runners := results.Count(summary.RowsLoaded("mlb_runner"))
+		pickoffs := results.Count(summary.RowsLoaded("mlb_pickoff"))
+		if runners != ex.ExpectedRunners || pickoffs != ex.ExpectedPickoffs {
+			t.Errorf("Load %d: expected %d runners and %d pickoffs, got %d and %d",
+				i+1, ex.ExpectedRunners, ex.ExpectedPickoffs, runners, pickoffs)
+		}
+	}
+
+	var runners, pickoffs int
+	bbdb.dbConn.QueryRowContext(context.Background(), "select count(*) from mlb_runner;").Scan(&runners)
+	bbdb.dbConn.QueryRowContext(context.Background(), "select count(*) from mlb_pickoff;").Scan(&pickoffs)
+	if runners != 1 || pickoffs != 1 {
+		t.Errorf("Expected the second load to replace the first, found %d runners and %d pickoffs", runners, pickoffs)
+	}
+
+	var runner, eventNum int
+	var base string
+	err = bbdb.dbConn.QueryRowContext(context.Background(), `select r.runner, p.event_num, p.base from mlb_runner r
+		join mlb_pickoff p using (game_date, away_team, home_team, game_number, at_bat_number);`).Scan(&runner, &eventNum, &base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner != 519317 || eventNum != 15 || base != "1B" {
+		t.Errorf("Expected Stanton's at bat to have a pickoff attempt at 1B (event 15), got runner %d, event %d at %s", runner, eventNum, base)
+	}
+}
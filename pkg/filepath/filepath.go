@@ -17,14 +17,18 @@
 package filepath
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
-	"os/user"
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
+
+	"github.com/bauer312/baseball/pkg/config"
+	"github.com/bauer312/baseball/pkg/manifest"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -32,40 +36,109 @@ FilePath turns a single date path into the paths of all
 	relevant files for that date
 */
 type FilePath struct {
-	FilePath chan string
-	basePath string
-	wg       sync.WaitGroup
+	FilePath    chan string
+	Force       bool
+	Workers     int
+	Compression string
+	basePath    string
+	downloads   *manifest.Manifest
+	wg          sync.WaitGroup
 }
 
 /*
 ChannelListener should be run in a goroutine and will receive paths on the FilePath
 	channel.  It will retrieve the data for that path and save it to a file in the
-	location specified.  Once the channel is closed, the goroutine exits
+	location specified.  Paths that the manifest shows were already downloaded
+	completely are skipped unless Force is set.  Up to Workers paths are
+	retrieved at the same time.  Files are stored in the format named by
+	Compression (see util.CompressedPath).  Once the channel is closed, the
+	goroutine exits.
+	When the context is cancelled, the paths still arriving are drained without
+	being requested.
 */
-func (fP *FilePath) ChannelListener(client *http.Client) {
-	for inputPath := range fP.FilePath {
-		fmt.Printf("\tRequesting %s\n", inputPath)
-		fP.wg.Add(1)
-		time.Sleep(4 * time.Second)
-		resp, err := client.Get(inputPath)
+func (fP *FilePath) ChannelListener(ctx context.Context, client util.Getter) {
+	defer fP.wg.Done()
+	workers := fP.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var workerWG sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		workerWG.Add(1)
+		go func() {
+			defer workerWG.Done()
+			for inputPath := range fP.FilePath {
+				if ctx.Err() == nil {
+					fP.download(ctx, client, inputPath)
+				}
+			}
+		}()
+	}
+	workerWG.Wait()
+}
+
+func (fP *FilePath) download(ctx context.Context, client util.Getter, inputPath string) {
+	if fP.Force == false && fP.downloads.Complete(inputPath) {
+		slog.Info("Skipping file, it has already been downloaded", "url", inputPath)
+		return
+	}
+	results := summary.FromContext(ctx)
+	results.Request(inputPath)
+	slog.Info("Requesting file", "url", inputPath)
+	resp, err := util.GetContext(ctx, client, inputPath)
+	if i := strings.Index(inputPath, "gid_"); i >= 0 {
+		outputPath := filepath.Join(fP.basePath, strings.Replace(inputPath[i:], "/", "_", -1))
+		outputPath = util.CompressedPath(outputPath, fP.Compression)
 		if err != nil {
-			fmt.Println(err.Error())
-		} else if i := strings.Index(inputPath, "gid_"); i >= 0 {
-			outputPath := filepath.Join(fP.basePath, strings.Replace(inputPath[i:], "/", "_", -1))
-			fP.writeFile(outputPath, resp)
+			slog.Error("Unable to retrieve file", "url", inputPath, "err", err)
+			fP.recordFile(inputPath, outputPath, 0)
+			results.Fail(inputPath, err)
+		} else if size, err := fP.writeFile(inputPath, outputPath, resp); err != nil {
+			results.Fail(inputPath, err)
+		} else {
+			results.Done(inputPath)
+			results.Add(summary.FilesDownloaded, 1)
+			results.Add(summary.BytesDownloaded, size)
 		}
-		fP.wg.Done()
+	} else if err != nil {
+		slog.Error("Unable to retrieve file", "url", inputPath, "err", err)
+		results.Fail(inputPath, err)
+	} else {
+		resp.Body.Close()
+		results.Done(inputPath)
 	}
 }
 
-func (fP *FilePath) writeFile(filePath string, resp *http.Response) {
-	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_RDWR, os.ModePerm)
+/*
+writeFile saves the body of the response (see util.SaveResponse).  An error
+	page or a download that did not finish is recorded as a failure and
+	doesn't touch the file on disk.
+*/
+func (fP *FilePath) writeFile(url, filePath string, resp *http.Response) (int64, error) {
+	size, err := util.SaveResponse(resp, filePath)
 	if err != nil {
-		fmt.Println(err)
-		return
+		slog.Error("Unable to save file", "url", url, "path", filePath, "err", err)
+		fP.recordFile(url, filePath, 0)
+		return 0, err
+	}
+	fP.recordFile(url, filePath, resp.StatusCode)
+	return size, nil
+}
+
+/*
+recordFile adds the download to the manifest.  A status of zero means that
+	the download did not finish.
+*/
+func (fP *FilePath) recordFile(url, filePath string, status int) {
+	var err error
+	if status == 0 {
+		err = fP.downloads.Record(manifest.Entry{URL: url, Path: filePath})
+	} else {
+		err = fP.downloads.RecordFile(url, filePath, status)
+	}
+	if err != nil {
+		slog.Error("Unable to update the download manifest", "url", url, "err", err)
 	}
-	defer f.Close()
-	resp.Write(f)
 }
 
 /*
@@ -73,25 +146,27 @@ Init will create all channels
 */
 func (fP *FilePath) Init(output string) {
 	fP.FilePath = make(chan string)
-	usr, err := user.Current()
-	if err != nil {
-		fmt.Println("Unable to determine user storage location")
-	}
+	fP.wg.Add(1)
 	if len(output) == 0 {
-		output = filepath.Join(usr.HomeDir, "baseball")
+		output = config.DefaultDataRoot()
 	}
 	fP.basePath = filepath.Join(output, "gameday/raw/")
-	err = os.MkdirAll(fP.basePath, 0740)
+	err := os.MkdirAll(fP.basePath, 0740)
+	if err != nil {
+		slog.Error("Unable to validate storage location", "path", fP.basePath, "err", err)
+	}
+	slog.Info("Storage location", "path", fP.basePath)
+	fP.downloads, err = manifest.Load(fP.basePath)
 	if err != nil {
-		fmt.Println("Unable to validate storage location")
+		slog.Error("Unable to read the download manifest", "err", err)
 	}
-	fmt.Printf("Storage Location: %s\n", fP.basePath)
 }
 
 /*
-Done will close the DatePath channel, signalling that we are done
+Done will close the FilePath channel, signalling that we are done, and then
+	wait for the ChannelListener to finish the requests it has received
 */
 func (fP *FilePath) Done() {
-	fP.wg.Wait()
 	close(fP.FilePath)
+	fP.wg.Wait()
 }
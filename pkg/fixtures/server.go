@@ -0,0 +1,346 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+/*
+Package fixtures is a stand in for gd2.mlb.com and baseballsavant.mlb.com.  It
+	serves a fixture tree laid out like the gameday site:
+		components/game/mlb/year_2019/month_06/day_10/master_scoreboard.xml
+		components/game/mlb/year_2019/month_06/day_10/gid_.../game.xml
+		savant/20190610.csv
+	Directories are answered with a listing of their contents, which is all the
+	gid_ parsers need, and the Statcast search is answered from the CSV of
+	each date in the range that was asked for.  The server is an http.Handler,
+	so it can be used with httptest as easily as with the serve-fixtures
+	command.
+*/
+package fixtures
+
+import (
+	"bytes"
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed tree
+var tree embed.FS
+
+/*
+Default returns the fixture tree that is built into the program: two days of
+	Yankees at Red Sox, June 10th and 11th 2019, with the gameday files and
+	the Statcast pitches of both games
+*/
+func Default() fs.FS {
+	files, err := fs.Sub(tree, "tree")
+	if err != nil {
+		panic(err)
+	}
+	return files
+}
+
+/*
+Server answers requests from a fixture tree, injecting any faults it has been
+	given along the way
+*/
+type Server struct {
+	// Delay is how long a slow response waits before it is answered
+	Delay  time.Duration
+	files  fs.FS
+	static http.Handler
+	mu     sync.Mutex
+	faults []*Fault
+}
+
+/*
+New creates a server for a fixture tree, such as Default() or os.DirFS(path)
+*/
+func New(files fs.FS, faults ...Fault) *Server {
+	s := &Server{
+		Delay:  5 * time.Second,
+		files:  files,
+		static: http.FileServer(http.FS(files)),
+	}
+	for i := range faults {
+		fault := faults[i]
+		s.faults = append(s.faults, &fault)
+	}
+	return s
+}
+
+/*
+ServeHTTP answers a single request
+*/
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Fixture request", "url", r.URL.RequestURI())
+
+	handler := http.Handler(http.HandlerFunc(s.serve))
+	if fault := s.fault(r.URL.RequestURI()); fault != nil {
+		slog.Info("Injecting a fault", "fault", fault.Kind, "url", r.URL.RequestURI())
+		switch fault.Kind {
+		case FaultNotFound:
+			handler = http.NotFoundHandler()
+		case FaultServerError:
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			})
+		case FaultSlow:
+			select {
+			case <-time.After(s.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		case FaultTruncate:
+			handler = truncate(handler)
+		}
+	}
+	handler.ServeHTTP(w, r)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/statcast_search/csv" {
+		s.statcast(w, r)
+		return
+	}
+	s.static.ServeHTTP(w, r)
+}
+
+/*
+fault returns the first fault that applies to a request, using up one of its
+	responses
+*/
+func (s *Server) fault(uri string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fault := range s.faults {
+		if strings.Contains(uri, fault.Match) == false {
+			continue
+		}
+		if fault.Count > 0 && fault.used >= fault.Count {
+			continue
+		}
+		fault.used++
+		return fault
+	}
+	return nil
+}
+
+/*
+statcast answers a Statcast search with the pitches of every date between
+	game_date_gt and game_date_lt.  The team, game type (hfGT) and player
+	lookups are applied; everything else in the query is ignored.
+*/
+func (s *Server) statcast(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, err := time.Parse("2006-01-02", query.Get("game_date_gt"))
+	if err != nil {
+		http.Error(w, "game_date_gt is required", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse("2006-01-02", query.Get("game_date_lt"))
+	if err != nil || end.Before(start) {
+		end = start
+	}
+
+	filters := make(map[string]map[string]bool)
+	addFilter := func(column string, values []string) {
+		for _, value := range values {
+			if len(value) == 0 {
+				continue
+			}
+			if filters[column] == nil {
+				filters[column] = make(map[string]bool)
+			}
+			filters[column][value] = true
+		}
+	}
+	addFilter("game_type", strings.Split(query.Get("hfGT"), "|"))
+	addFilter("batter", query["batters_lookup[]"])
+	addFilter("pitcher", query["pitchers_lookup[]"])
+	team := query.Get("team")
+
+	var buffer bytes.Buffer
+	out := csv.NewWriter(&buffer)
+	var header []string
+	for date := start; date.After(end) == false; date = date.AddDate(0, 0, 1) {
+		data, err := fs.ReadFile(s.files, "savant/"+date.Format("20060102")+".csv")
+		if err != nil {
+			continue
+		}
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if header == nil {
+			header = rows[0]
+			out.Write(header)
+		}
+		columns := make(map[string]int)
+		for i, name := range rows[0] {
+			if _, ok := columns[name]; ok == false {
+				columns[name] = i
+			}
+		}
+		for _, row := range rows[1:] {
+			if matches(row, columns, filters, team) {
+				out.Write(row)
+			}
+		}
+	}
+	out.Flush()
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Write(buffer.Bytes())
+}
+
+/*
+matches decides whether a Statcast row passes the filters of a search
+*/
+func matches(row []string, columns map[string]int, filters map[string]map[string]bool, team string) bool {
+	value := func(column string) string {
+		i, ok := columns[column]
+		if ok == false || i >= len(row) {
+			return ""
+		}
+		return row[i]
+	}
+	for column, allowed := range filters {
+		if allowed[value(column)] == false {
+			return false
+		}
+	}
+	if len(team) > 0 && value("home_team") != team && value("away_team") != team {
+		return false
+	}
+	return true
+}
+
+/*
+truncate sends the response headers as if the whole body was coming, with
+	the real Content-Length, but only half of the body
+*/
+func truncate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded := &recorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(recorded, r)
+
+		for name, values := range recorded.header {
+			w.Header()[name] = values
+		}
+		body := recorded.body.Bytes()
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(recorded.status)
+		w.Write(body[:len(body)/2])
+	})
+}
+
+/*
+recorder keeps a whole response so that it can be changed before it is sent
+*/
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	rec.status = status
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	return rec.body.Write(p)
+}
+
+/*
+Fault kinds
+*/
+const (
+	FaultNotFound    = "404"
+	FaultServerError = "500"
+	FaultSlow        = "slow"
+	FaultTruncate    = "truncate"
+)
+
+/*
+Fault describes a failure to inject into the responses whose URL contains
+	Match.  Count limits the number of responses that fail; zero means
+	every one of them.
+*/
+type Fault struct {
+	Kind  string
+	Match string
+	Count int
+	used  int
+}
+
+/*
+ParseFault reads a fault written as kind[:match[:count]], for example
+	404:inning_all.xml, 500:game.xml:2 or slow:day_10.  Without a match the
+	fault applies to every request.
+*/
+func ParseFault(value string) (Fault, error) {
+	parts := strings.SplitN(value, ":", 3)
+	fault := Fault{Kind: strings.ToLower(parts[0])}
+	switch fault.Kind {
+	case FaultNotFound, FaultServerError, FaultSlow, FaultTruncate:
+	default:
+		return fault, fmt.Errorf("unknown fault %s (expected 404, 500, slow or truncate)", parts[0])
+	}
+	if len(parts) > 1 {
+		fault.Match = parts[1]
+	}
+	if len(parts) > 2 {
+		count, err := strconv.Atoi(parts[2])
+		if err != nil || count < 0 {
+			return fault, fmt.Errorf("invalid count in fault %s", value)
+		}
+		fault.Count = count
+	}
+	return fault, nil
+}
+
+/*
+ParseFaults reads a comma separated list of faults (see ParseFault)
+*/
+func ParseFaults(value string) ([]Fault, error) {
+	var faults []Fault
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		fault, err := ParseFault(part)
+		if err != nil {
+			return nil, err
+		}
+		faults = append(faults, fault)
+	}
+	return faults, nil
+}
@@ -0,0 +1,90 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fixtures
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	faults, err := ParseFaults("404:game_events.xml:1,500:day_11/master,truncate:inning_all.xml,slow:day_12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := New(Default(), faults...)
+	server.Delay = time.Millisecond
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	day := "/components/game/mlb/year_2019/month_06/day_10/"
+	gid := day + "gid_2019_06_10_nyamlb_bosmlb_1/"
+	savant := "/statcast_search/csv?all=true&hfGT=R|&player_type=pitcher&game_date_gt=2019-06-10&game_date_lt=2019-06-11&"
+
+	var serverTest = []struct {
+		Path          string
+		ExpectedCode  int
+		ExpectedBody  string
+		ExpectedLines int
+		ExpectedError bool
+	}{
+		{day, http.StatusOK, `href="gid_2019_06_10_nyamlb_bosmlb_1/"`, 0, false},
+		{strings.TrimSuffix(day, "/"), http.StatusOK, `href="gid_2019_06_10_nyamlb_bosmlb_1/"`, 0, false},
+		{day + "master_scoreboard.xml", http.StatusOK, `game_pk="565000"`, 0, false},
+		{gid + "game.xml", http.StatusOK, `<stadium id="3"`, 0, false},
+		{gid + "game_events.xml", http.StatusNotFound, "", 0, false},
+		{gid + "game_events.xml", http.StatusOK, `<atbat num="1"`, 0, false},
+		{gid + "inning/inning_all.xml", http.StatusOK, "", 0, true},
+		{"/components/game/mlb/year_2019/month_06/day_11/master_scoreboard.xml", http.StatusInternalServerError, "", 0, false},
+		{"/components/game/mlb/year_2019/month_06/day_12/", http.StatusNotFound, "", 0, false},
+		{savant + "team=", http.StatusOK, "pitch_type,game_date", 49, false},
+		{savant + "team=NYY", http.StatusOK, "pitch_type,game_date", 49, false},
+		{savant + "team=HOU", http.StatusOK, "pitch_type,game_date", 1, false},
+		{strings.Replace(savant, "hfGT=R|", "hfGT=PO|", 1) + "team=", http.StatusOK, "pitch_type,game_date", 1, false},
+		{"/statcast_search/csv?game_date_gt=2019-06-12&game_date_lt=2019-06-12&", http.StatusOK, "", 0, false},
+	}
+
+	for _, ex := range serverTest {
+		resp, err := http.Get(ts.URL + ex.Path)
+		if err != nil {
+			t.Errorf("Unable to get %s: %s", ex.Path, err)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if (err != nil) != ex.ExpectedError {
+			t.Errorf("%s: unexpected read error %v", ex.Path, err)
+		}
+		if resp.StatusCode != ex.ExpectedCode {
+			t.Errorf("Status codes do not match for %s -> %d vs %d", ex.Path, resp.StatusCode, ex.ExpectedCode)
+		}
+		if strings.Contains(string(body), ex.ExpectedBody) == false {
+			t.Errorf("Body of %s does not contain %s", ex.Path, ex.ExpectedBody)
+		}
+		if lines := strings.Count(string(body), "\n"); ex.ExpectedLines > 0 && lines != ex.ExpectedLines {
+			t.Errorf("Expected %d lines from %s, got %d", ex.ExpectedLines, ex.Path, lines)
+		}
+	}
+
+	if _, err := ParseFault("teapot:game.xml"); err == nil {
+		t.Errorf("Expected an error for an unknown fault")
+	}
+}
@@ -0,0 +1,202 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package manifest
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/*
+FileName is the name of the manifest file kept in each output directory
+*/
+const FileName = "manifest.jsonl"
+
+/*
+Entry records the outcome of a single download
+*/
+type Entry struct {
+	URL       string    `json:"url"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	Status    int       `json:"status"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+/*
+Succeeded is true when the server answered the request with a 2xx status
+*/
+func (e Entry) Succeeded() bool {
+	return e.Status >= 200 && e.Status < 300
+}
+
+/*
+Manifest is the persistent list of downloads made into a directory.  It is
+	stored as one JSON entry per line and every new entry is appended as
+	soon as it is recorded, so a crash loses at most the download that was
+	in flight.  When a URL appears more than once, the last entry wins.
+*/
+type Manifest struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+/*
+Load reads the manifest kept in the directory, if there is one.  The manifest
+	that is returned can always be used, even when an error is returned; it
+	just won't know about the downloads that could not be read.
+*/
+func Load(dir string) (*Manifest, error) {
+	m := &Manifest{
+		path:    filepath.Join(dir, FileName),
+		entries: make(map[string]Entry),
+	}
+
+	f, err := os.Open(m.path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		// A line that was only partially written before a crash is ignored
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			m.entries[e.URL] = e
+		}
+	}
+	return m, scanner.Err()
+}
+
+/*
+Lookup returns the most recent entry for a URL
+*/
+func (m *Manifest) Lookup(url string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[url]
+	return e, ok
+}
+
+/*
+Entries returns every entry in the manifest
+*/
+func (m *Manifest) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+/*
+Complete is true when the URL was fetched successfully and the file on disk
+	still has the size and checksum that were recorded at the time
+*/
+func (m *Manifest) Complete(url string) bool {
+	e, ok := m.Lookup(url)
+	if ok == false || e.Succeeded() == false {
+		return false
+	}
+	info, err := os.Stat(e.Path)
+	if err != nil || info.Size() != e.Size {
+		return false
+	}
+	_, sum, err := HashFile(e.Path)
+	if err != nil {
+		return false
+	}
+	return sum == e.SHA256
+}
+
+/*
+Record adds an entry to the manifest and appends it to the manifest file
+*/
+func (m *Manifest) Record(e Entry) error {
+	if e.FetchedAt.IsZero() {
+		e.FetchedAt = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[e.URL] = e
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+/*
+RecordFile fills in the size and checksum of a downloaded file and records it
+*/
+func (m *Manifest) RecordFile(url, path string, status int) error {
+	size, sum, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	return m.Record(Entry{
+		URL:    url,
+		Path:   path,
+		Size:   size,
+		SHA256: sum,
+		Status: status,
+	})
+}
+
+/*
+HashFile returns the size and the hex-encoded SHA-256 of a file
+*/
+func HashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,78 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestComplete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	good := filepath.Join(dir, "good.csv")
+	partial := filepath.Join(dir, "partial.csv")
+	failed := filepath.Join(dir, "failed.csv")
+	for _, f := range []string{good, partial, failed} {
+		err = ioutil.WriteFile(f, []byte("pitch_type,game_date\nFF,2019-06-10\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.RecordFile("http://test/good", good, 200)
+	m.RecordFile("http://test/partial", partial, 200)
+	m.RecordFile("http://test/failed", failed, 500)
+
+	// Simulate a download that was cut short after it was recorded
+	err = ioutil.WriteFile(partial, []byte("pitch_type,game_date\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything has to survive a reload from disk
+	m, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var completeTest = []struct {
+		URL      string
+		Complete bool
+	}{
+		{"http://test/good", true},
+		{"http://test/partial", false},
+		{"http://test/failed", false},
+		{"http://test/missing", false},
+	}
+
+	for _, ex := range completeTest {
+		if m.Complete(ex.URL) != ex.Complete {
+			t.Errorf("Unexpected completion status for %s -> %t", ex.URL, !ex.Complete)
+		}
+	}
+}
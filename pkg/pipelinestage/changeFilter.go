@@ -0,0 +1,103 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bauer312/baseball/pkg/records"
+)
+
+/*
+ChangeFilter contains the elements of a pipeline stage that passes a record on
+	only when it is new or different from the last version of the same record
+	(see records.Key).  Placed after a stage that reads the same file over and
+	over, it turns a series of snapshots into a series of changes.
+*/
+type ChangeFilter struct {
+	DataInput  chan string
+	DataOutput chan string
+	wg         sync.WaitGroup
+	last       map[string]string
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+/*
+Init will create all channels and other initialization needs.
+	The DataInput channel is the output of any previous
+	pipeline stage so it shouldn't be created here
+*/
+func (cF *ChangeFilter) Init(ctx context.Context) error {
+	cF.ctx, cF.cancel = context.WithCancel(ctx)
+	cF.wg.Add(1)
+	cF.DataOutput = make(chan string)
+	cF.last = make(map[string]string)
+	return nil
+}
+
+/*
+Stop will close the input channel, causing Run to stop
+*/
+func (cF *ChangeFilter) Stop() {
+	close(cF.DataInput)
+	cF.wg.Wait()
+	cF.cancel()
+}
+
+/*
+Abort the pipeline stage immediately
+*/
+func (cF *ChangeFilter) Abort() {
+	cF.cancel()
+}
+
+/*
+Run should be run in a goroutine and will receive records on the input channel
+*/
+func (cF *ChangeFilter) Run() {
+	defer cF.wg.Done()
+	for {
+		var inputData string
+		select {
+		case data, ok := <-cF.DataInput:
+			if ok == false {
+				return
+			}
+			inputData = data
+		case <-cF.ctx.Done():
+			return
+		}
+
+		// A record without a known identity is only a repeat if it is identical
+		key, ok := records.Key(inputData)
+		if ok == false {
+			key = inputData
+		}
+		if last, ok := cF.last[key]; ok && last == inputData {
+			continue
+		}
+		cF.last[key] = inputData
+
+		select {
+		case cF.DataOutput <- inputData:
+		case <-cF.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChangeFilter(t *testing.T) {
+	inProgress := `{"RecordName":"GameStatusRecord","ID":565997,"Status":"In Progress","Inning":3}`
+	laterInning := `{"RecordName":"GameStatusRecord","ID":565997,"Status":"In Progress","Inning":4}`
+	otherGame := `{"RecordName":"GameStatusRecord","ID":565998,"Status":"Preview","Inning":0}`
+	unknown := `not a record`
+
+	var changeTest = []struct {
+		InputData  []string
+		OutputData []string
+	}{
+		{
+			InputData:  []string{inProgress, otherGame, inProgress, otherGame, laterInning, inProgress, unknown, unknown},
+			OutputData: []string{inProgress, otherGame, laterInning, inProgress, unknown},
+		},
+	}
+
+	for _, ex := range changeTest {
+		cF := ChangeFilter{DataInput: make(chan string)}
+		cF.Init(context.Background())
+		go cF.Run()
+
+		var output []string
+		done := make(chan bool)
+		go func() {
+			for data := range cF.DataOutput {
+				output = append(output, data)
+			}
+			done <- true
+		}()
+
+		for _, input := range ex.InputData {
+			cF.DataInput <- input
+		}
+		cF.Stop()
+		close(cF.DataOutput)
+		<-done
+
+		if len(output) != len(ex.OutputData) {
+			t.Fatalf("Expected %d records, received %d: %v", len(ex.OutputData), len(output), output)
+		}
+		for i, expected := range ex.OutputData {
+			if output[i] != expected {
+				t.Errorf("Output element %d mismatch: expected %s but received %s", i, expected, output[i])
+			}
+		}
+	}
+}
@@ -17,17 +17,15 @@
 package pipelinestage
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"log/slog"
 	"sync"
 
 	records "github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
 	"github.com/bauer312/baseball/pkg/util"
-
-	//Make sure we can use Postgres
-	_ "github.com/lib/pq"
 )
 
 /*
@@ -36,19 +34,24 @@ DatabaseOutput contains the elements of a pipeline stage that will accept
 */
 type DatabaseOutput struct {
 	DataInput []chan string
+	DSN       string
 	wg        sync.WaitGroup
-	db        *sql.DB
+	db        *util.Database
+	mu        sync.Mutex
 	tables    map[string]bool
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 /*
 Init the pipeline stage,
 */
-func (dbO *DatabaseOutput) Init() error {
+func (dbO *DatabaseOutput) Init(ctx context.Context) error {
+	dbO.ctx, dbO.cancel = context.WithCancel(ctx)
 	numChannels := len(dbO.DataInput)
 	dbO.wg.Add(numChannels)
 
-	db, err := util.GetDBConnection()
+	db, err := util.GetDBConnection(dbO.DSN)
 	if err != nil {
 		return err
 	}
@@ -67,23 +70,19 @@ func (dbO *DatabaseOutput) Stop() {
 		close(channel)
 	}
 	dbO.wg.Wait()
+	dbO.cancel()
 	err := dbO.db.Close()
 	if err != nil {
-		fmt.Println("Error when closing the baseball database")
+		slog.Error("Error when closing the baseball database", "err", err)
 	}
 }
 
 /*
-Abort the pipeline stage immediately
+Abort the pipeline stage immediately.  Statements that are running are
+	cancelled; the database is closed by Stop.
 */
 func (dbO *DatabaseOutput) Abort() {
-	for _, channel := range dbO.DataInput {
-		close(channel)
-	}
-	err := dbO.db.Close()
-	if err != nil {
-		fmt.Println("Error when closing the baseball database")
-	}
+	dbO.cancel()
 }
 
 /*
@@ -97,115 +96,68 @@ func (dbO *DatabaseOutput) Run() {
 
 func (dbO *DatabaseOutput) runChannelInput(input chan string) {
 	defer dbO.wg.Done()
-	for inputData := range input {
-		dbO.wg.Add(1)
-		dbO.updateRecord(inputData)
-		dbO.wg.Done()
+	for {
+		select {
+		case inputData, ok := <-input:
+			if ok == false {
+				return
+			}
+			dbO.updateRecord(inputData)
+		case <-dbO.ctx.Done():
+			return
+		}
 	}
 }
 
 func (dbO *DatabaseOutput) updateRecord(record string) {
 	// Grab the record type from the JSON-formatted string
-	if strings.HasPrefix(record, "{\"RecordName\":") == true {
-		endOfType := strings.Index(record[15:], "\"") + 15
-		recordType := record[15:endOfType]
+	recordType, ok := records.Name(record)
+	if ok == false {
+		return
+	}
+	results := summary.FromContext(dbO.ctx)
+	rec, ok := records.New(recordType)
+	if ok == false {
+		slog.Warn("Unexpected record type", "type", recordType)
+		results.Fail(recordType, fmt.Errorf("unexpected record type"))
+		return
+	}
+	err := json.Unmarshal([]byte(record), rec)
+	if err != nil {
+		slog.Error("Unable to unmarshal record", "type", recordType, "err", err)
+		results.Fail(record, err)
+		return
+	}
 
-		_, tableCreated := dbO.tables[recordType]
-		if tableCreated == false {
-			dbO.tables[recordType] = false
-		}
+	err = dbO.confirmTable(recordType, rec)
+	if err != nil {
+		slog.Error("Unable to create table", "table", recordType, "err", err)
+		results.Fail(record, err)
+		return
+	}
+	err = rec.UpdateRecord(dbO.ctx, dbO.db)
+	if err != nil {
+		slog.Error("Unable to update record", "table", recordType, "err", err)
+		results.Fail(record, err)
+		return
+	}
+	results.Add(summary.RowsLoaded(recordType), 1)
+}
 
-		switch recordType {
-		case "VenueRecord":
-			var vR records.VenueRecord
-			err := json.Unmarshal([]byte(record), &vR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal VenueRecord")
-			}
-			if tableCreated == false {
-				vR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			vR.UpdateRecord(dbO.db)
-		case "LeagueRecord":
-			var lR records.LeagueRecord
-			err := json.Unmarshal([]byte(record), &lR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal League Record")
-			}
-			if tableCreated == false {
-				lR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			lR.UpdateRecord(dbO.db)
-		case "DivisionRecord":
-			var dR records.DivisionRecord
-			err := json.Unmarshal([]byte(record), &dR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal DivisionRecord")
-			}
-			if tableCreated == false {
-				dR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			dR.UpdateRecord(dbO.db)
-		case "TeamRecord":
-			var tR records.TeamRecord
-			err := json.Unmarshal([]byte(record), &tR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal TeamRecord")
-			}
-			if tableCreated == false {
-				tR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			tR.UpdateRecord(dbO.db)
-		case "StandingRecord":
-			var sR records.StandingRecord
-			err := json.Unmarshal([]byte(record), &sR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal StandingRecord")
-			}
-			if tableCreated == false {
-				sR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			sR.UpdateRecord(dbO.db)
-		case "GameRecord":
-			var gR records.GameRecord
-			err := json.Unmarshal([]byte(record), &gR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal GameRecord")
-			}
-			if tableCreated == false {
-				gR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			gR.UpdateRecord(dbO.db)
-		case "GameStatusRecord":
-			var gsR records.GameStatusRecord
-			err := json.Unmarshal([]byte(record), &gsR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal GameStatusRecord")
-			}
-			if tableCreated == false {
-				gsR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			gsR.UpdateRecord(dbO.db)
-		case "InningScoreRecord":
-			var isR records.InningScoreRecord
-			err := json.Unmarshal([]byte(record), &isR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal InningScoreRecord")
-			}
-			if tableCreated == false {
-				isR.CreateTable(dbO.db)
-				dbO.tables[recordType] = true
-			}
-			isR.UpdateRecord(dbO.db)
-		default:
-			fmt.Printf("Unexpected record type %s", recordType)
-		}
+/*
+confirmTable creates the table of a record type the first time one of its
+	records arrives
+*/
+func (dbO *DatabaseOutput) confirmTable(recordType string, rec records.Records) error {
+	dbO.mu.Lock()
+	defer dbO.mu.Unlock()
+	if dbO.tables[recordType] {
+		return nil
 	}
+	err := rec.CreateTable(dbO.ctx, dbO.db)
+	if err != nil {
+		return err
+	}
+	dbO.tables[recordType] = true
+	return nil
 }
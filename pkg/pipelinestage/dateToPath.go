@@ -17,11 +17,14 @@
 package pipelinestage
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/bauer312/baseball/pkg/catalog"
 	"github.com/bauer312/baseball/pkg/dateslice"
+	"github.com/bauer312/baseball/pkg/summary"
 )
 
 /*
@@ -36,19 +39,24 @@ type DateInputParameters struct {
 DateToPath contains the elements of a pipeline stage that will accept a
 	set of date inputDataeters and, for each date, a path to the page
     containing data for that date.  Input and output are byte arrays
-    containing marshalled JSON data.
+    containing marshalled JSON data.  The path is in the gameday tree of
+    Sport (see catalog.DayPath), which is the major leagues when empty.
 */
 type DateToPath struct {
 	DataInput  chan DateInputParameters
 	DataOutput chan string
 	BaseURL    string
+	Sport      string
 	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
 }
 
 /*
 Init the pipeline stage,
 */
-func (dP *DateToPath) Init() error {
+func (dP *DateToPath) Init(ctx context.Context) error {
+	dP.ctx, dP.cancel = context.WithCancel(ctx)
 	dP.wg.Add(1)
 	dP.DataOutput = make(chan string)
 	return nil
@@ -60,13 +68,14 @@ Stop the pipeline stage in a graceful manner
 func (dP *DateToPath) Stop() {
 	close(dP.DataInput)
 	dP.wg.Wait()
+	dP.cancel()
 }
 
 /*
 Abort the pipeline stage immediately
 */
 func (dP *DateToPath) Abort() {
-	close(dP.DataInput)
+	dP.cancel()
 }
 
 /*
@@ -74,23 +83,36 @@ Run the pipeline stage
 */
 func (dP *DateToPath) Run() {
 	defer dP.wg.Done()
-	// This loop runs until the input channel is closed
-	for inputData := range dP.DataInput {
+	// This loop runs until the input channel is closed or the stage is aborted
+	for {
+		var inputData DateInputParameters
+		select {
+		case data, ok := <-dP.DataInput:
+			if ok == false {
+				return
+			}
+			inputData = data
+		case <-dP.ctx.Done():
+			return
+		}
+
 		var dates []time.Time
 		if len(inputData.Beg) != 0 && len(inputData.End) != 0 {
 			dates = dateslice.RangeString(inputData.Beg, inputData.End)
 		} else if len(inputData.Beg) != 0 && len(inputData.End) == 0 {
 			dates = dateslice.RangeString(inputData.Beg, inputData.Beg)
 		} else {
-			fmt.Println("Invalid Date Input")
+			slog.Error("Invalid date input", "beg", inputData.Beg, "end", inputData.End)
 			return
 		}
+		summary.FromContext(dP.ctx).Add(summary.DatesRequested, int64(len(dates)))
 
 		for _, date := range dates {
-			year := date.Year()
-			month := date.Month()
-			day := date.Day()
-			dP.DataOutput <- fmt.Sprintf("%s/components/game/mlb/year_%04d/month_%02d/day_%02d/", dP.BaseURL, year, month, day)
+			select {
+			case dP.DataOutput <- catalog.DayPath(dP.BaseURL, dP.Sport, date) + "/":
+			case <-dP.ctx.Done():
+				return
+			}
 		}
 	}
 }
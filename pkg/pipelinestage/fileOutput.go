@@ -17,16 +17,15 @@
 package pipelinestage
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"os"
-	"os/user"
 	"path"
-	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/bauer312/baseball/pkg/config"
 	"github.com/bauer312/baseball/pkg/records"
 )
 
@@ -45,28 +44,31 @@ FileOutput contains the elements of a pipeline stage that will accept
 */
 type FileOutput struct {
 	DataInput []chan string
+	BasePath  string
 	wg        sync.WaitGroup
 	basePath  string
+	mu        sync.Mutex
 	files     map[string]*os.File
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 /*
 Init the pipeline stage,
 */
-func (fO *FileOutput) Init() error {
+func (fO *FileOutput) Init(ctx context.Context) error {
+	fO.ctx, fO.cancel = context.WithCancel(ctx)
 	numChannels := len(fO.DataInput)
 	fO.wg.Add(numChannels)
 
-	usr, err := user.Current()
-	if err != nil {
-		fmt.Println("Unable to determine user storage location")
-		return err
+	fO.basePath = fO.BasePath
+	if len(fO.basePath) == 0 {
+		fO.basePath = config.DefaultDataRoot()
 	}
-	fO.basePath = filepath.Join(usr.HomeDir, "baseball/")
 
-	err = os.MkdirAll(fO.basePath, os.ModePerm)
+	err := os.MkdirAll(fO.basePath, os.ModePerm)
 	if err != nil {
-		fmt.Println("Unable to validate storage location")
+		slog.Error("Unable to validate storage location", "path", fO.basePath, "err", err)
 		return err
 	}
 
@@ -83,6 +85,7 @@ func (fO *FileOutput) Stop() {
 		close(channel)
 	}
 	fO.wg.Wait()
+	fO.cancel()
 
 	for _, filePtr := range fO.files {
 		filePtr.Close()
@@ -90,15 +93,11 @@ func (fO *FileOutput) Stop() {
 }
 
 /*
-Abort the pipeline stage immediately
+Abort the pipeline stage immediately.  The files are closed by Stop, once the
+	records that were being written have finished.
 */
 func (fO *FileOutput) Abort() {
-	for _, channel := range fO.DataInput {
-		close(channel)
-	}
-	for _, filePtr := range fO.files {
-		filePtr.Close()
-	}
+	fO.cancel()
 }
 
 /*
@@ -112,85 +111,43 @@ func (fO *FileOutput) Run() {
 
 func (fO *FileOutput) runChannelInput(input chan string) {
 	defer fO.wg.Done()
-	for inputData := range input {
-		fO.wg.Add(1)
-		fO.writeRecord(inputData)
-		fO.wg.Done()
+	for {
+		select {
+		case inputData, ok := <-input:
+			if ok == false {
+				return
+			}
+			fO.writeRecord(inputData)
+		case <-fO.ctx.Done():
+			return
+		}
 	}
 }
 
 func (fO *FileOutput) writeRecord(record string) {
 	// Grab the record type from the JSON-formatted string
-	if strings.HasPrefix(record, "{\"RecordName\":") == true {
-		endOfType := strings.Index(record[15:], "\"") + 15
-		recordType := record[15:endOfType]
-
-		_, ok := fO.files[recordType]
-		if ok == false {
-			fO.openFile(recordType)
-		}
+	recordType, ok := records.Name(record)
+	if ok == false {
+		return
+	}
+	rec, ok := records.New(recordType)
+	if ok == false {
+		slog.Warn("Unexpected record type", "type", recordType)
+		return
+	}
+	err := json.Unmarshal([]byte(record), rec)
+	if err != nil {
+		slog.Error("Unable to unmarshal record", "type", recordType, "err", err)
+		return
+	}
 
-		switch recordType {
-		case "VenueRecord":
-			var vR records.VenueRecord
-			err := json.Unmarshal([]byte(record), &vR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal VenueRecord")
-			}
-			vR.FileOutput(fO.files[recordType])
-		case "LeagueRecord":
-			var lR records.LeagueRecord
-			err := json.Unmarshal([]byte(record), &lR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal League Record")
-			}
-			lR.FileOutput(fO.files[recordType])
-		case "DivisionRecord":
-			var dR records.DivisionRecord
-			err := json.Unmarshal([]byte(record), &dR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal DivisionRecord")
-			}
-			dR.FileOutput(fO.files[recordType])
-		case "TeamRecord":
-			var tR records.TeamRecord
-			err := json.Unmarshal([]byte(record), &tR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal TeamRecord")
-			}
-			tR.FileOutput(fO.files[recordType])
-		case "StandingRecord":
-			var sR records.StandingRecord
-			err := json.Unmarshal([]byte(record), &sR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal StandingRecord")
-			}
-			sR.FileOutput(fO.files[recordType])
-		case "GameRecord":
-			var gR records.GameRecord
-			err := json.Unmarshal([]byte(record), &gR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal GameRecord")
-			}
-			gR.FileOutput(fO.files[recordType])
-		case "GameStatusRecord":
-			var gsR records.GameStatusRecord
-			err := json.Unmarshal([]byte(record), &gsR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal GameStatusRecord")
-			}
-			gsR.FileOutput(fO.files[recordType])
-		case "InningScoreRecord":
-			var isR records.InningScoreRecord
-			err := json.Unmarshal([]byte(record), &isR)
-			if err != nil {
-				fmt.Println("Unable to unmarshal InningScoreRecord")
-			}
-			isR.FileOutput(fO.files[recordType])
-		default:
-			fmt.Printf("Unexpected record type %s", recordType)
-		}
+	fO.mu.Lock()
+	defer fO.mu.Unlock()
+	_, ok = fO.files[recordType]
+	if ok == false {
+		fO.openFile(recordType)
 	}
+	rec.FileOutput(fO.files[recordType])
 }
 
 func (fO *FileOutput) openFile(recordType string) {
@@ -198,7 +155,7 @@ func (fO *FileOutput) openFile(recordType string) {
 	filePath := path.Join(fO.basePath, fileName)
 	ptr, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_RDWR, os.ModePerm)
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("Unable to open the record file", "path", filePath, "err", err)
 		return
 	}
 	fO.files[recordType] = ptr
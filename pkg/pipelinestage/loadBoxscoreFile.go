@@ -0,0 +1,332 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	records "github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+BoxscoreFile contains the elements of a pipeline stage that will accept the
+	game data directories sent by the scoreboard stage and turn the boxscore
+	(bis_boxscore.xml) of each game into batting and pitching line records
+*/
+type BoxscoreFile struct {
+	DataInput  chan string
+	DataOutput chan string
+	BaseURL    string
+	Client     util.Getter
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+/*
+BoxscoreXML describes the boxscore structure present in the bis_boxscore.xml file
+*/
+type BoxscoreXML struct {
+	GameID     string                `xml:"game_id,attr"`
+	GamePK     int64                 `xml:"game_pk,attr"`
+	HomeTeamID int64                 `xml:"home_id,attr"`
+	AwayTeamID int64                 `xml:"away_id,attr"`
+	Date       string                `xml:"date,attr"`
+	StatusInd  string                `xml:"status_ind,attr"`
+	Batting    []BoxscoreXMLBatting  `xml:"batting"`
+	Pitching   []BoxscoreXMLPitching `xml:"pitching"`
+}
+
+/*
+BoxscoreXMLBatting describes the batting lines of one team in the bis_boxscore.xml file
+*/
+type BoxscoreXMLBatting struct {
+	TeamFlag string              `xml:"team_flag,attr"`
+	Batters  []BoxscoreXMLBatter `xml:"batter"`
+}
+
+/*
+BoxscoreXMLBatter describes the batting line of one player in the bis_boxscore.xml file
+*/
+type BoxscoreXMLBatter struct {
+	ID           int64  `xml:"id,attr"`
+	Name         string `xml:"name,attr"`
+	Position     string `xml:"pos,attr"`
+	BattingOrder int    `xml:"bo,attr"`
+	AtBats       int    `xml:"ab,attr"`
+	Runs         int    `xml:"r,attr"`
+	Hits         int    `xml:"h,attr"`
+	RBI          int    `xml:"rbi,attr"`
+	Walks        int    `xml:"bb,attr"`
+	Strikeouts   int    `xml:"so,attr"`
+	HomeRuns     int    `xml:"hr,attr"`
+	StolenBases  int    `xml:"sb,attr"`
+}
+
+/*
+BoxscoreXMLPitching describes the pitching lines of one team in the bis_boxscore.xml file
+*/
+type BoxscoreXMLPitching struct {
+	TeamFlag string               `xml:"team_flag,attr"`
+	Pitchers []BoxscoreXMLPitcher `xml:"pitcher"`
+}
+
+/*
+BoxscoreXMLPitcher describes the pitching line of one pitcher in the bis_boxscore.xml
+	file.  The decision is given by the win, loss and save attributes and
+	repeated in the note, such as "(W, 8-2)"; older files only have the note.
+*/
+type BoxscoreXMLPitcher struct {
+	ID           int64  `xml:"id,attr"`
+	Name         string `xml:"name,attr"`
+	Outs         int    `xml:"out,attr"`
+	BattersFaced int    `xml:"bf,attr"`
+	Hits         int    `xml:"h,attr"`
+	Runs         int    `xml:"r,attr"`
+	EarnedRuns   int    `xml:"er,attr"`
+	Walks        int    `xml:"bb,attr"`
+	Strikeouts   int    `xml:"so,attr"`
+	HomeRuns     int    `xml:"hr,attr"`
+	Pitches      int    `xml:"np,attr"`
+	Strikes      int    `xml:"s,attr"`
+	Win          string `xml:"win,attr"`
+	Loss         string `xml:"loss,attr"`
+	Save         string `xml:"save,attr"`
+	Note         string `xml:"note,attr"`
+}
+
+/*
+decision reports whether the pitcher was given the decision written as
+	letter in the note, or marked with the matching attribute
+*/
+func (p BoxscoreXMLPitcher) decision(attr string, letter string) bool {
+	if ok, err := strconv.ParseBool(attr); err == nil && ok {
+		return true
+	}
+	return strings.HasPrefix(p.Note, "("+letter+",")
+}
+
+/*
+Run should be run in a goroutine and will receive game data directories on the
+	input channel, such as /components/game/mlb/year_2019/month_06/day_10/gid_...
+	It will add the boxscore file (bis_boxscore.xml) to the directory, retrieve
+	it, and send a batting line record for every batter and a pitching line
+	record for every pitcher in the game.
+*/
+func (bsF *BoxscoreFile) Run() {
+	defer bsF.wg.Done()
+
+	results := summary.FromContext(bsF.ctx)
+	for {
+		var inputData string
+		select {
+		case data, ok := <-bsF.DataInput:
+			if ok == false {
+				return
+			}
+			inputData = data
+		case <-bsF.ctx.Done():
+			return
+		}
+		boxscoreURL := gameDirectoryURL(bsF.BaseURL, inputData) + "bis_boxscore.xml"
+
+		results.Request(boxscoreURL)
+		resp, err := util.GetContext(bsF.ctx, bsF.Client, boxscoreURL)
+		if err != nil {
+			slog.Error("Unable to retrieve boxscore", "url", boxscoreURL, "err", err)
+			results.Fail(boxscoreURL, err)
+			continue
+		}
+		err = bsF.tokenize(inputData, resp)
+		if err != nil {
+			slog.Error("Unable to process boxscore", "url", boxscoreURL, "err", err)
+			results.Fail(boxscoreURL, err)
+		} else {
+			results.Done(boxscoreURL)
+		}
+	}
+}
+
+/*
+Init will create all channels and other initialization needs.
+	The DataInput channel is the output of any previous
+	pipeline stage so it shouldn't be created here
+*/
+func (bsF *BoxscoreFile) Init(ctx context.Context) error {
+	bsF.ctx, bsF.cancel = context.WithCancel(ctx)
+	bsF.wg.Add(1)
+	bsF.DataOutput = make(chan string)
+
+	return nil
+}
+
+/*
+Stop will close the input channel, causing Run to stop
+*/
+func (bsF *BoxscoreFile) Stop() {
+	close(bsF.DataInput)
+	bsF.wg.Wait()
+	bsF.cancel()
+}
+
+/*
+Abort the pipeline stage immediately
+*/
+func (bsF *BoxscoreFile) Abort() {
+	bsF.cancel()
+}
+
+/*
+gameDirectoryURL turns a game data directory into a URL ending in a slash.
+	The scoreboard gives the directory relative to the gameday site.
+*/
+func gameDirectoryURL(baseURL, gameDirectory string) string {
+	if strings.HasPrefix(gameDirectory, "/") {
+		gameDirectory = strings.TrimSuffix(baseURL, "/") + gameDirectory
+	}
+	if strings.HasSuffix(gameDirectory, "/") == false {
+		gameDirectory = gameDirectory + "/"
+	}
+	return gameDirectory
+}
+
+/*
+tokenize parses a boxscore file and sends its records on.  A game that has not
+	started yet has no boxscore, so a missing file is not an error.  The
+	records are effective as of the date of the game, from the boxscore or
+	else from the game directory, so that a mirror of the gameday tree gives
+	the same records as the network does.
+*/
+func (bsF *BoxscoreFile) tokenize(gameDirectory string, resp *http.Response) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		slog.Info("The boxscore has not been published", "url", resp.Request.URL.String())
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to retrieve the boxscore: %s", resp.Status)
+	}
+
+	var bs BoxscoreXML
+	decoder := xml.NewDecoder(resp.Body)
+	err := decoder.Decode(&bs)
+	if err != nil {
+		return err
+	}
+
+	effectiveDate, err := time.Parse("2006-01-02", bs.Date)
+	if err != nil {
+		effectiveDate, err = gameDirectoryDate(gameDirectory)
+		if err != nil {
+			return fmt.Errorf("unable to parse the date of game %d (%s): %w", bs.GamePK, bs.Date, err)
+		}
+	}
+
+	for _, batting := range bs.Batting {
+		teamID, err := bs.teamID(batting.TeamFlag)
+		if err != nil {
+			return err
+		}
+		for _, batter := range batting.Batters {
+			bsF.sendJSONToOutput(json.Marshal(records.BattingLineRecord{
+				RecordName:    "BattingLineRecord",
+				EffectiveDate: effectiveDate,
+				GameID:        bs.GamePK,
+				PlayerID:      batter.ID,
+				TeamID:        teamID,
+				Name:          batter.Name,
+				Position:      batter.Position,
+				BattingOrder:  batter.BattingOrder,
+				AtBats:        batter.AtBats,
+				Runs:          batter.Runs,
+				Hits:          batter.Hits,
+				RBI:           batter.RBI,
+				Walks:         batter.Walks,
+				Strikeouts:    batter.Strikeouts,
+				HomeRuns:      batter.HomeRuns,
+				StolenBases:   batter.StolenBases,
+			}))
+		}
+	}
+
+	for _, pitching := range bs.Pitching {
+		teamID, err := bs.teamID(pitching.TeamFlag)
+		if err != nil {
+			return err
+		}
+		for _, pitcher := range pitching.Pitchers {
+			bsF.sendJSONToOutput(json.Marshal(records.PitchingLineRecord{
+				RecordName:    "PitchingLineRecord",
+				EffectiveDate: effectiveDate,
+				GameID:        bs.GamePK,
+				PlayerID:      pitcher.ID,
+				TeamID:        teamID,
+				Name:          pitcher.Name,
+				Outs:          pitcher.Outs,
+				BattersFaced:  pitcher.BattersFaced,
+				Hits:          pitcher.Hits,
+				Runs:          pitcher.Runs,
+				EarnedRuns:    pitcher.EarnedRuns,
+				Walks:         pitcher.Walks,
+				Strikeouts:    pitcher.Strikeouts,
+				HomeRuns:      pitcher.HomeRuns,
+				Pitches:       pitcher.Pitches,
+				Strikes:       pitcher.Strikes,
+				Win:           pitcher.decision(pitcher.Win, "W"),
+				Loss:          pitcher.decision(pitcher.Loss, "L"),
+				Save:          pitcher.decision(pitcher.Save, "S"),
+			}))
+		}
+	}
+	return bsF.ctx.Err()
+}
+
+/*
+teamID returns the ID of the away or home team
+*/
+func (bs *BoxscoreXML) teamID(teamFlag string) (int64, error) {
+	switch teamFlag {
+	case "away":
+		return bs.AwayTeamID, nil
+	case "home":
+		return bs.HomeTeamID, nil
+	}
+	return 0, fmt.Errorf("unexpected team flag encountered in game %d: %s", bs.GamePK, teamFlag)
+}
+
+func (bsF *BoxscoreFile) sendJSONToOutput(rep []byte, err error) {
+	if err != nil {
+		slog.Error("Unable to encode record", "err", err)
+		return
+	}
+	select {
+	case bsF.DataOutput <- string(rep):
+	case <-bsF.ctx.Done():
+	}
+}
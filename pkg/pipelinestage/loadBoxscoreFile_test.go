@@ -0,0 +1,123 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/records"
+)
+
+func TestBoxscoreFile(t *testing.T) {
+	// A local mirror answers with the time the file was copied, which is not
+	//	the date of the game
+	server := fixtures.New(fixtures.Default())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	bsF := BoxscoreFile{
+		DataInput: make(chan string),
+		BaseURL:   ts.URL,
+		Client:    http.DefaultClient,
+	}
+	bsF.Init(context.Background())
+	go bsF.Run()
+
+	var output []string
+	done := make(chan bool)
+	go func() {
+		for data := range bsF.DataOutput {
+			output = append(output, data)
+		}
+		done <- true
+	}()
+
+	bsF.DataInput <- "/components/game/mlb/year_2019/month_06/day_11/gid_2019_06_11_nyamlb_bosmlb_1"
+	// A game that hasn't started has no boxscore
+	bsF.DataInput <- "/components/game/mlb/year_2019/month_06/day_11/gid_2019_06_11_houmlb_bosmlb_1"
+	bsF.Stop()
+	close(bsF.DataOutput)
+	<-done
+
+	batting := make(map[int64]records.BattingLineRecord)
+	pitching := make(map[int64]records.PitchingLineRecord)
+	for _, data := range output {
+		name, _ := records.Name(data)
+		switch name {
+		case "BattingLineRecord":
+			var blR records.BattingLineRecord
+			if err := json.Unmarshal([]byte(data), &blR); err != nil {
+				t.Fatal(err)
+			}
+			batting[blR.PlayerID] = blR
+		case "PitchingLineRecord":
+			var plR records.PitchingLineRecord
+			if err := json.Unmarshal([]byte(data), &plR); err != nil {
+				t.Fatal(err)
+			}
+			pitching[plR.PlayerID] = plR
+		default:
+			t.Errorf("Unexpected record %s", data)
+		}
+	}
+	if len(batting) != 4 || len(pitching) != 2 {
+		t.Fatalf("Expected 4 batting and 2 pitching lines, received %d and %d", len(batting), len(pitching))
+	}
+
+	judge := batting[592450]
+	if judge.GameID != 565012 || judge.TeamID != 147 || judge.BattingOrder != 100 || judge.AtBats != 4 ||
+		judge.Runs != 3 || judge.Hits != 2 || judge.RBI != 3 || judge.Strikeouts != 1 || judge.HomeRuns != 1 {
+		t.Errorf("Unexpected batting line for Judge: %+v", judge)
+	}
+	if judge.EffectiveDate.Format("20060102") != "20190611" {
+		t.Errorf("Expected the batting line to be effective on the date of the game, not %s", judge.EffectiveDate)
+	}
+	if batting[593428].TeamID != 111 || batting[593428].StolenBases != 1 {
+		t.Errorf("Unexpected batting line for Bogaerts: %+v", batting[593428])
+	}
+
+	var pitchingTest = []struct {
+		PlayerID int64
+		TeamID   int64
+		Runs     int
+		Win      bool
+		Loss     bool
+	}{
+		{543037, 147, 1, true, false},
+		{519242, 111, 3, false, true},
+	}
+	for _, ex := range pitchingTest {
+		plR := pitching[ex.PlayerID]
+		if plR.TeamID != ex.TeamID || plR.Outs != 24 || plR.Runs != ex.Runs || plR.EarnedRuns != ex.Runs ||
+			plR.Pitches != 104 || plR.Strikes != 70 || plR.Win != ex.Win || plR.Loss != ex.Loss || plR.Save {
+			t.Errorf("Unexpected pitching line for %d: %+v", ex.PlayerID, plR)
+		}
+	}
+
+	if (BoxscoreXMLPitcher{Note: "(S, 12)"}).decision("", "S") == false {
+		t.Errorf("Expected a save from the note")
+	}
+}
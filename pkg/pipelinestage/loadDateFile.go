@@ -17,11 +17,13 @@
 package pipelinestage
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 
+	"github.com/bauer312/baseball/pkg/util"
 	"golang.org/x/net/html"
 )
 
@@ -41,12 +43,19 @@ ChannelListener should be run in a goroutine and will receive data on the input
 	and the input control channel.  The parameters are converted to a slice of time
 	elements and these elements are then sent out over the output channel.
 */
-func (dF *DateFile) ChannelListener(client *http.Client) {
+func (dF *DateFile) ChannelListener(ctx context.Context, client util.Getter) {
 	for inputData := range dF.DataInput {
+		// Once the context is cancelled, the rest of the input is drained
+		//	without being requested
+		if ctx.Err() != nil {
+			continue
+		}
 		dF.rwg.Add(1)
-		resp, err := client.Get(inputData)
+		resp, err := util.GetContext(ctx, client, inputData)
 		if err != nil {
-			fmt.Println(err.Error())
+			slog.Error("Unable to retrieve file", "url", inputData, "err", err)
+			dF.rwg.Done()
+			continue
 		}
 		dF.tokenize(inputData, resp)
 	}
@@ -17,6 +17,7 @@
 package pipelinestage
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -61,7 +62,7 @@ func TestLoadingDateFile(t *testing.T) {
 		data.DF.Init()
 		// DataInput channels don't get created automatically
 		data.DF.DataInput = make(chan string)
-		go data.DF.ChannelListener(&http.Client{Timeout: (3 * time.Second)})
+		go data.DF.ChannelListener(context.Background(), &http.Client{Timeout: (3 * time.Second)})
 
 		// Start the anonymous function that receives the output of the method under test
 		data.WG.Add(1)
@@ -17,12 +17,16 @@
 package pipelinestage
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -123,12 +127,19 @@ ChannelListener should be run in a goroutine and will receive data on the input
 	and the input control channel.  The parameters are converted to a slice of time
 	elements and these elements are then sent out over the output channel.
 */
-func (gE *GameEventsFile) ChannelListener(client *http.Client) {
+func (gE *GameEventsFile) ChannelListener(ctx context.Context, client util.Getter) {
 	for inputData := range gE.DataInput {
+		// Once the context is cancelled, the rest of the input is drained
+		//	without being requested
+		if ctx.Err() != nil {
+			continue
+		}
 		gE.rwg.Add(1)
-		resp, err := client.Get(inputData)
+		resp, err := util.GetContext(ctx, client, inputData)
 		if err != nil {
-			fmt.Println(err.Error())
+			slog.Error("Unable to retrieve file", "url", inputData, "err", err)
+			gE.rwg.Done()
+			continue
 		}
 		gE.tokenize(inputData, resp)
 	}
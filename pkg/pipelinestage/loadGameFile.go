@@ -17,13 +17,17 @@
 package pipelinestage
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -83,12 +87,19 @@ ChannelListener should be run in a goroutine and will receive data on the input
 	and the input control channel.  The parameters are converted to a slice of time
 	elements and these elements are then sent out over the output channel.
 */
-func (gF *GameFile) ChannelListener(client *http.Client) {
+func (gF *GameFile) ChannelListener(ctx context.Context, client util.Getter) {
 	for inputData := range gF.DataInput {
+		// Once the context is cancelled, the rest of the input is drained
+		//	without being requested
+		if ctx.Err() != nil {
+			continue
+		}
 		gF.rwg.Add(1)
-		resp, err := client.Get(inputData)
+		resp, err := util.GetContext(ctx, client, inputData)
 		if err != nil {
-			fmt.Println(err.Error())
+			slog.Error("Unable to retrieve file", "url", inputData, "err", err)
+			gF.rwg.Done()
+			continue
 		}
 		gF.tokenize(inputData, resp)
 	}
@@ -0,0 +1,360 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	records "github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+PlayersFile contains the elements of a pipeline stage that will accept the
+	game data directories sent by the scoreboard stage and turn the roster
+	of each game (players.xml) into player records, and its umpire crew into
+	umpire and umpire assignment records.  The birth date of each player
+	comes from the batters/ and pitchers/ files of the game, which are only
+	retrieved the first time the stage sees the player.  The roster doesn't
+	have the game_pk that the assignments need, so it comes with the game
+	directory from the scoreboard.
+*/
+type PlayersFile struct {
+	DataInput  chan GameDirectory
+	DataOutput chan string
+	BaseURL    string
+	Client     util.Getter
+	birthDates map[int64]string
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+/*
+PlayersXML describes the game structure present in the players.xml file
+*/
+type PlayersXML struct {
+	Teams []struct {
+		Type    string             `xml:"type,attr"`
+		Players []PlayersXMLPlayer `xml:"player"`
+	} `xml:"team"`
+	Umpires []PlayersXMLUmpire `xml:"umpires>umpire"`
+}
+
+/*
+PlayersXMLPlayer describes the player structure present in the players.xml file
+*/
+type PlayersXMLPlayer struct {
+	ID       int64  `xml:"id,attr"`
+	First    string `xml:"first,attr"`
+	Last     string `xml:"last,attr"`
+	Number   string `xml:"num,attr"`
+	BoxName  string `xml:"boxname,attr"`
+	Throws   string `xml:"rl,attr"`
+	Bats     string `xml:"bats,attr"`
+	Position string `xml:"position,attr"`
+	TeamID   int64  `xml:"team_id,attr"`
+}
+
+/*
+PlayersXMLUmpire describes the umpire structure present in the players.xml file.
+	The position is home, first, second or third, or left and right in the
+	postseason.
+*/
+type PlayersXMLUmpire struct {
+	ID       int64  `xml:"id,attr"`
+	Position string `xml:"position,attr"`
+	First    string `xml:"first,attr"`
+	Last     string `xml:"last,attr"`
+}
+
+/*
+PlayerXML describes the player structure present in the batters/ and
+	pitchers/ files of a game, such as batters/592450.xml
+*/
+type PlayerXML struct {
+	ID        int64  `xml:"id,attr"`
+	Type      string `xml:"type,attr"`
+	FirstName string `xml:"first_name,attr"`
+	LastName  string `xml:"last_name,attr"`
+	BirthDate string `xml:"dob,attr"`
+}
+
+/*
+Run should be run in a goroutine and will receive the games of the scoreboard
+	on the input channel.  It will add the roster file (players.xml) to the
+	directory, retrieve it, and send a player record for everybody on it.
+*/
+func (pF *PlayersFile) Run() {
+	defer pF.wg.Done()
+
+	results := summary.FromContext(pF.ctx)
+	for {
+		var inputData GameDirectory
+		select {
+		case data, ok := <-pF.DataInput:
+			if ok == false {
+				return
+			}
+			inputData = data
+		case <-pF.ctx.Done():
+			return
+		}
+		gameURL := gameDirectoryURL(pF.BaseURL, inputData.Path)
+		playersURL := gameURL + "players.xml"
+
+		results.Request(playersURL)
+		resp, err := util.GetContext(pF.ctx, pF.Client, playersURL)
+		if err != nil {
+			slog.Error("Unable to retrieve players", "url", playersURL, "err", err)
+			results.Fail(playersURL, err)
+			continue
+		}
+		err = pF.tokenize(gameURL, inputData.GameID, resp)
+		if err != nil {
+			slog.Error("Unable to process players", "url", playersURL, "err", err)
+			results.Fail(playersURL, err)
+		} else {
+			results.Done(playersURL)
+		}
+	}
+}
+
+/*
+Init will create all channels and other initialization needs.
+	The DataInput channel is the output of any previous
+	pipeline stage so it shouldn't be created here
+*/
+func (pF *PlayersFile) Init(ctx context.Context) error {
+	pF.ctx, pF.cancel = context.WithCancel(ctx)
+	pF.wg.Add(1)
+	pF.DataOutput = make(chan string)
+	pF.birthDates = make(map[int64]string)
+
+	return nil
+}
+
+/*
+Stop will close the input channel, causing Run to stop
+*/
+func (pF *PlayersFile) Stop() {
+	close(pF.DataInput)
+	pF.wg.Wait()
+	pF.cancel()
+}
+
+/*
+Abort the pipeline stage immediately
+*/
+func (pF *PlayersFile) Abort() {
+	pF.cancel()
+}
+
+/*
+tokenize parses a players file and sends its records on.  A game that has not
+	started yet has no roster, so a missing file is not an error.  The
+	records are effective as of the date of the game.  The file is read and
+	closed before the birth dates are looked up.
+*/
+func (pF *PlayersFile) tokenize(gameURL string, gameID int64, resp *http.Response) error {
+	players, err := readPlayers(resp)
+	if err != nil || players == nil {
+		return err
+	}
+
+	effectiveDate, err := gameDirectoryDate(gameURL)
+	if err != nil {
+		return err
+	}
+
+	results := summary.FromContext(pF.ctx)
+	for _, team := range players.Teams {
+		for _, player := range team.Players {
+			birthDate, err := pF.birthDate(gameURL, player)
+			if err != nil {
+				// A version without the birth date would be wrong, so leave
+				//	the player for the next run
+				slog.Error("Unable to find the birth date of player", "url", gameURL, "player", player.ID, "err", err)
+				results.Fail(fmt.Sprintf("%splayer %d", gameURL, player.ID), err)
+				continue
+			}
+			pF.sendJSONToOutput(json.Marshal(records.PlayerRecord{
+				RecordName:    "PlayerRecord",
+				EffectiveDate: effectiveDate,
+				ID:            player.ID,
+				FirstName:     player.First,
+				LastName:      player.Last,
+				BoxName:       player.BoxName,
+				Number:        player.Number,
+				BirthDate:     birthDate,
+				Bats:          player.Bats,
+				Throws:        player.Throws,
+				Position:      player.Position,
+				TeamID:        player.TeamID,
+			}))
+		}
+	}
+
+	if len(players.Umpires) == 0 {
+		return pF.ctx.Err()
+	}
+	if gameID == 0 {
+		return fmt.Errorf("the umpires of %s can't be assigned without the game_pk of the game", gameURL)
+	}
+	for _, umpire := range players.Umpires {
+		pF.sendJSONToOutput(json.Marshal(records.UmpireRecord{
+			RecordName:    "UmpireRecord",
+			EffectiveDate: effectiveDate,
+			ID:            umpire.ID,
+			FirstName:     umpire.First,
+			LastName:      umpire.Last,
+		}))
+		pF.sendJSONToOutput(json.Marshal(records.GameUmpireAssignmentRecord{
+			RecordName:    "GameUmpireAssignmentRecord",
+			EffectiveDate: effectiveDate,
+			GameID:        gameID,
+			Position:      umpirePosition(umpire.Position),
+			UmpireID:      umpire.ID,
+		}))
+	}
+	return pF.ctx.Err()
+}
+
+/*
+readPlayers decodes a players file and closes it.  A roster that hasn't been
+	published yet is nil.
+*/
+func readPlayers(resp *http.Response) (*PlayersXML, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		slog.Info("The players have not been published", "url", resp.Request.URL.String())
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to retrieve the players: %s", resp.Status)
+	}
+
+	var players PlayersXML
+	decoder := xml.NewDecoder(resp.Body)
+	err := decoder.Decode(&players)
+	if err != nil {
+		return nil, err
+	}
+	return &players, nil
+}
+
+/*
+umpirePosition turns the position of an umpire in players.xml into the short
+	form used by the assignment records.  A position that isn't known is
+	kept as it is.
+*/
+func umpirePosition(position string) string {
+	switch position {
+	case "home":
+		return "HP"
+	case "first":
+		return "1B"
+	case "second":
+		return "2B"
+	case "third":
+		return "3B"
+	case "left":
+		return "LF"
+	case "right":
+		return "RF"
+	}
+	return position
+}
+
+/*
+birthDate returns the birth date of a player as YYYY-MM-DD, retrieving the
+	batter or pitcher file of the game the first time the player is seen.
+	A player without a file, or without a birth date in it, has an empty
+	birth date.  A file that can't be retrieved or read is an error and
+	is tried again the next time the player is seen.
+*/
+func (pF *PlayersFile) birthDate(gameURL string, player PlayersXMLPlayer) (string, error) {
+	if birthDate, ok := pF.birthDates[player.ID]; ok {
+		return birthDate, nil
+	}
+
+	directory := "batters/"
+	if player.Position == "P" {
+		directory = "pitchers/"
+	}
+	playerURL := gameURL + directory + strconv.FormatInt(player.ID, 10) + ".xml"
+	resp, err := util.GetContext(pF.ctx, pF.Client, playerURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		slog.Warn("Unable to retrieve player", "url", playerURL, "status", resp.Status)
+		pF.birthDates[player.ID] = ""
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to retrieve the player: %s", resp.Status)
+	}
+
+	var p PlayerXML
+	decoder := xml.NewDecoder(resp.Body)
+	err = decoder.Decode(&p)
+	if err != nil {
+		return "", err
+	}
+	var birthDate string
+	if dob, err := time.Parse("01/02/2006", p.BirthDate); err == nil {
+		birthDate = dob.Format("2006-01-02")
+	}
+	pF.birthDates[player.ID] = birthDate
+	return birthDate, nil
+}
+
+func (pF *PlayersFile) sendJSONToOutput(rep []byte, err error) {
+	if err != nil {
+		slog.Error("Unable to encode record", "err", err)
+		return
+	}
+	select {
+	case pF.DataOutput <- string(rep):
+	case <-pF.ctx.Done():
+	}
+}
+
+/*
+gameDirectoryDate returns the date of a game from the gid_YYYY_MM_DD_ part of
+	its directory
+*/
+func gameDirectoryDate(gameDirectory string) (time.Time, error) {
+	i := strings.Index(gameDirectory, "gid_")
+	if i < 0 || len(gameDirectory) < i+14 {
+		return time.Time{}, fmt.Errorf("unable to find the date of the game in %s", gameDirectory)
+	}
+	return time.Parse("2006_01_02", gameDirectory[i+4:i+14])
+}
@@ -0,0 +1,151 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+func TestPlayersFile(t *testing.T) {
+	var playerRequests int64
+	server := fixtures.New(fixtures.Default(), fixtures.Fault{Kind: fixtures.FaultNotFound, Match: "batters/593428.xml"},
+		fixtures.Fault{Kind: fixtures.FaultServerError, Match: "pitchers/519242.xml", Count: 1})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/batters/") || strings.Contains(r.URL.Path, "/pitchers/") {
+			atomic.AddInt64(&playerRequests, 1)
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	// A single worker must be enough for the roster and the lookups it needs
+	results := summary.New("pipeline")
+	pF := PlayersFile{
+		DataInput: make(chan GameDirectory),
+		BaseURL:   ts.URL,
+		Client:    util.NewFetcher(http.DefaultClient, util.FetcherConfig{Workers: 1}),
+	}
+	pF.Init(summary.NewContext(context.Background(), results))
+	go pF.Run()
+
+	var output []records.PlayerRecord
+	var umpires []records.UmpireRecord
+	var assignments []records.GameUmpireAssignmentRecord
+	done := make(chan bool)
+	go func() {
+		for data := range pF.DataOutput {
+			var err error
+			name, _ := records.Name(data)
+			switch name {
+			case "PlayerRecord":
+				var pR records.PlayerRecord
+				err = json.Unmarshal([]byte(data), &pR)
+				output = append(output, pR)
+			case "UmpireRecord":
+				var uR records.UmpireRecord
+				err = json.Unmarshal([]byte(data), &uR)
+				umpires = append(umpires, uR)
+			case "GameUmpireAssignmentRecord":
+				var guaR records.GameUmpireAssignmentRecord
+				err = json.Unmarshal([]byte(data), &guaR)
+				assignments = append(assignments, guaR)
+			default:
+				t.Errorf("Unexpected record %s", data)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		done <- true
+	}()
+
+	pF.DataInput <- GameDirectory{Path: "/components/game/mlb/year_2019/month_06/day_10/gid_2019_06_10_nyamlb_bosmlb_1", GameID: 565000}
+	pF.DataInput <- GameDirectory{Path: "/components/game/mlb/year_2019/month_06/day_11/gid_2019_06_11_nyamlb_bosmlb_1", GameID: 565012}
+	pF.Stop()
+	close(pF.DataOutput)
+	<-done
+
+	// Chris Sale is left out of the first game, whose pitcher file failed,
+	//	rather than being loaded without a birth date
+	if len(output) != 11 {
+		t.Fatalf("Expected 11 player records, received %d", len(output))
+	}
+	if results.Failed() != 1 {
+		t.Errorf("Expected the failed pitcher file to be recorded, received %d failures", results.Failed())
+	}
+	// The birth date of each player is only looked up once it has been found
+	if playerRequests != 7 {
+		t.Errorf("Expected 7 batter and pitcher requests, received %d", playerRequests)
+	}
+
+	var playerTest = []struct {
+		Index     int
+		ID        int64
+		Name      string
+		BirthDate string
+		Throws    string
+		Position  string
+		TeamID    int64
+		Date      string
+	}{
+		{0, 592450, "Aaron Judge", "1992-04-26", "R", "RF", 147, "20190610"},
+		{2, 543037, "Gerrit Cole", "1990-09-08", "L", "P", 147, "20190610"},
+		{4, 593428, "Xander Bogaerts", "", "R", "SS", 111, "20190610"},
+		{10, 519242, "Chris Sale", "1989-03-30", "L", "P", 111, "20190611"},
+	}
+	for _, ex := range playerTest {
+		pR := output[ex.Index]
+		if pR.ID != ex.ID || pR.FullName() != ex.Name || pR.BirthDate != ex.BirthDate || pR.Throws != ex.Throws ||
+			pR.Position != ex.Position || pR.TeamID != ex.TeamID || pR.EffectiveDate.Format("20060102") != ex.Date {
+			t.Errorf("Unexpected player record %d: %+v", ex.Index, pR)
+		}
+	}
+
+	if len(umpires) != 8 || len(assignments) != 8 {
+		t.Fatalf("Expected 8 umpire and assignment records, received %d and %d", len(umpires), len(assignments))
+	}
+	if uR := umpires[4]; uR.ID != 427044 || uR.FirstName != "Joe" || uR.LastName != "West" || uR.EffectiveDate.Format("20060102") != "20190611" {
+		t.Errorf("Unexpected umpire record: %+v", uR)
+	}
+	var assignmentTest = []struct {
+		Index    int
+		GameID   int64
+		Position string
+		UmpireID int64
+	}{
+		{0, 565000, "HP", 427044},
+		{3, 565000, "3B", 427103},
+		{5, 565012, "1B", 427095},
+	}
+	for _, ex := range assignmentTest {
+		guaR := assignments[ex.Index]
+		if guaR.GameID != ex.GameID || guaR.Position != ex.Position || guaR.UmpireID != ex.UmpireID {
+			t.Errorf("Unexpected umpire assignment %d: %+v", ex.Index, guaR)
+		}
+	}
+}
@@ -17,29 +17,61 @@
 package pipelinestage
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/bauer312/baseball/pkg/catalog"
 	records "github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
-ScoreBoardFile contains the elements of the stage
+ScoreBoardFile contains the elements of the stage.  ReadOutput is optional;
+	when it is set, the stage sends a ScoreboardRead on it once each scoreboard
+	has been read and all of its records sent on.
 */
 type ScoreBoardFile struct {
 	DataInput      chan string
 	DataOutput     chan string
-	GameFileOutout chan string
+	GameFileOutout chan GameDirectory
+	ReadOutput     chan ScoreboardRead
 	BaseURL        string
-	Client         *http.Client
+	Client         util.Getter
+	games          map[string]bool
+	statuses       map[int64]string
 	wg             sync.WaitGroup
 	rwg            sync.WaitGroup
+	ctx            context.Context
+	cancel         context.CancelFunc
+}
+
+/*
+GameDirectory is a game found on a scoreboard: the directory that holds its
+	files and its game_pk, which the roster in the directory doesn't have
+*/
+type GameDirectory struct {
+	Path   string
+	GameID int64
+}
+
+/*
+ScoreboardRead is the outcome of reading one scoreboard: the status of each of
+	its games, keyed by game_pk, or the error that stopped it from being read
+*/
+type ScoreboardRead struct {
+	URL      string
+	Statuses map[int64]string
+	Err      error
 }
 
 /*
@@ -127,6 +159,33 @@ type ScoreboardXMLGame struct {
 	League                string                     `xml:"league,attr"`
 	Status                ScoreboardXMLGameStatus    `xml:"status"`
 	Linescore             ScoreboardXMLGameLinescore `xml:"linescore"`
+	AwayProbablePitcher   ScoreboardXMLPitcher       `xml:"away_probable_pitcher"`
+	HomeProbablePitcher   ScoreboardXMLPitcher       `xml:"home_probable_pitcher"`
+	WinningPitcher        ScoreboardXMLPitcher       `xml:"winning_pitcher"`
+	LosingPitcher         ScoreboardXMLPitcher       `xml:"losing_pitcher"`
+	SavePitcher           ScoreboardXMLPitcher       `xml:"save_pitcher"`
+}
+
+/*
+ScoreboardXMLPitcher describes the probable, winning, losing and save pitcher
+	structures present in the master_scoreboard.xml file.  The probable
+	pitchers have first_name and last_name where the others have first and
+	last.  Every attribute is empty when there is no such pitcher, such as
+	the save pitcher of a game without a save.
+*/
+type ScoreboardXMLPitcher struct {
+	ID                string `xml:"id,attr"`
+	First             string `xml:"first,attr"`
+	Last              string `xml:"last,attr"`
+	FirstName         string `xml:"first_name,attr"`
+	LastName          string `xml:"last_name,attr"`
+	Number            string `xml:"number,attr"`
+	ThrowingHand      string `xml:"throwinghand,attr"`
+	Wins              string `xml:"wins,attr"`
+	Losses            string `xml:"losses,attr"`
+	Saves             string `xml:"saves,attr"`
+	SaveOpportunities string `xml:"svo,attr"`
+	ERA               string `xml:"era,attr"`
 }
 
 /*
@@ -191,23 +250,53 @@ Run should be run in a goroutine and will receive URLs on the input channel.
         2. Game data contained in the file to be stored for future use
 */
 func (sbF *ScoreBoardFile) Run() {
-	for inputData := range sbF.DataInput {
+	//Tell the pipeline we are done once every request has been tokenized
+	defer sbF.wg.Done()
+	defer sbF.rwg.Wait()
+
+	results := summary.FromContext(sbF.ctx)
+	for {
+		var inputData string
+		select {
+		case data, ok := <-sbF.DataInput:
+			if ok == false {
+				return
+			}
+			inputData = data
+		case <-sbF.ctx.Done():
+			return
+		}
 		if strings.HasSuffix(inputData, "/") == false {
 			inputData = inputData + "/"
 		}
 		inputData = inputData + "master_scoreboard.xml"
 
 		sbF.rwg.Add(1)
-		resp, err := sbF.Client.Get(inputData)
+		results.Request(inputData)
+		sbF.statuses = make(map[int64]string)
+		resp, err := util.GetContext(sbF.ctx, sbF.Client, inputData)
 		if err != nil {
-			fmt.Println(err.Error())
+			slog.Error("Unable to retrieve scoreboard", "url", inputData, "err", err)
+			results.Fail(inputData, err)
+			sbF.rwg.Done()
+		} else {
+			err = sbF.tokenize(inputData, resp)
+			if err != nil {
+				slog.Error("Unable to process scoreboard", "url", inputData, "err", err)
+				results.Fail(inputData, err)
+			} else {
+				results.Done(inputData)
+			}
 		}
-		sbF.tokenize(inputData, resp)
-	}
-	sbF.rwg.Wait()
 
-	//Tell the pipeline we are done
-	sbF.wg.Done()
+		if sbF.ReadOutput != nil {
+			select {
+			case sbF.ReadOutput <- ScoreboardRead{URL: inputData, Statuses: sbF.statuses, Err: err}:
+			case <-sbF.ctx.Done():
+				return
+			}
+		}
+	}
 }
 
 /*
@@ -215,10 +304,12 @@ Init will create all channels and other initialization needs.
 	The DataInput channel is the output of any previous
 	pipeline stage so it shouldn't be created here
 */
-func (sbF *ScoreBoardFile) Init() error {
+func (sbF *ScoreBoardFile) Init(ctx context.Context) error {
+	sbF.ctx, sbF.cancel = context.WithCancel(ctx)
 	sbF.wg.Add(1)
 	sbF.DataOutput = make(chan string)
-	sbF.GameFileOutout = make(chan string)
+	sbF.GameFileOutout = make(chan GameDirectory)
+	sbF.games = make(map[string]bool)
 
 	return nil
 }
@@ -229,322 +320,425 @@ Stop will close the input channel, causing the Channel Listener to stop
 func (sbF *ScoreBoardFile) Stop() {
 	close(sbF.DataInput)
 	sbF.wg.Wait()
+	sbF.cancel()
+}
+
+/*
+Abort the pipeline stage immediately
+*/
+func (sbF *ScoreBoardFile) Abort() {
+	sbF.cancel()
 }
 
-func (sbF *ScoreBoardFile) tokenize(dataPath string, resp *http.Response) {
+/*
+tokenize parses a scoreboard file and sends its games and records on.  A game
+	that can't be understood is recorded as a failure and skipped, so that the
+	rest of the file is still processed.
+*/
+func (sbF *ScoreBoardFile) tokenize(dataPath string, resp *http.Response) error {
 	defer resp.Body.Close()
 	defer sbF.rwg.Done()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to retrieve the scoreboard: %s", resp.Status)
+	}
+
 	var sb ScoreboardXMLGames
 	decoder := xml.NewDecoder(resp.Body)
 	err := decoder.Decode(&sb)
 	if err != nil {
-		return
+		return err
 	}
 
-	EasternLocation, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		fmt.Printf("Unable to get location for the eastern time zone: %s", err.Error())
-		return
-	}
-	timeFormat := "2006/01/02 3:04PM"
-	//t, _ := time.ParseInLocation(longForm, "Jul 9, 2012 at 5:02am (CEST)", loc)
-	//fmt.Println(t)
-
-	venues := make([]records.VenueRecord, len(sb.Games))
-	leagues := make([]records.LeagueRecord, len(sb.Games)*2)
-	divisions := make([]records.DivisionRecord, len(sb.Games)*2)
-	teams := make([]records.TeamRecord, len(sb.Games)*2)
-	standings := make([]records.StandingRecord, len(sb.Games)*2)
-	games := make([]records.GameRecord, len(sb.Games))
-	gameStatuses := make([]records.GameStatusRecord, len(sb.Games))
-
+	results := summary.FromContext(sbF.ctx)
 	for i, game := range sb.Games {
 		//First, output the game directory
-		sbF.GameFileOutout <- game.GameDataDirectory
-
-		//Second, create all data records
-		timeString := game.DateTime + game.AMPM
-		var usedLocation *time.Location
-		switch game.TimeZone {
-		case "ET":
-			usedLocation = EasternLocation
-		default:
-			fmt.Printf("Unexpected Time Zone encountered: %s", game.TimeZone)
-			return
-		}
-		gameTime, err := time.ParseInLocation(timeFormat, timeString, usedLocation)
-		if err != nil {
-			fmt.Printf("Unable to parse the timestamp of game %d (%s): %s", i, timeString, err.Error())
-			return
-		}
-		venueID, err := strconv.ParseInt(game.VenueID, 10, 64)
-		if err != nil {
-			fmt.Printf("Unable to parse the venue ID of game %d (%s): %s", i, game.VenueID, err.Error())
-			return
-		}
-		awayLeagueID, err := strconv.ParseInt(game.AwayLeagueID, 10, 64)
-		if err != nil {
-			fmt.Printf("Unable to parse the away league ID of game %d (%s): %s", i, game.AwayLeagueID, err.Error())
-			return
+		// The same scoreboard may be read more than once (see ChangeFilter)
+		if sbF.games[game.GameDataDirectory] == false {
+			sbF.games[game.GameDataDirectory] = true
+			results.Add(summary.GamesFound, 1)
 		}
-		homeLeagueID, err := strconv.ParseInt(game.HomeLeagueID, 10, 64)
-		if err != nil {
-			fmt.Printf("Unable to parse the home league ID of game %d (%s): %s", i, game.HomeLeagueID, err.Error())
-			return
-		}
-		var awayLeagueName string
-		switch awayLeagueID {
-		case 103:
-			awayLeagueName = "American League"
-		case 104:
-			awayLeagueName = "National League"
-		default:
-			fmt.Printf("Unexpected league ID ecountered: %d", awayLeagueID)
-			return
-		}
-		var homeLeagueName string
-		switch homeLeagueID {
-		case 103:
-			homeLeagueName = "American League"
-		case 104:
-			homeLeagueName = "National League"
-		default:
-			fmt.Printf("Unexpected league ID ecountered: %d", homeLeagueID)
-			return
+		select {
+		case sbF.GameFileOutout <- GameDirectory{Path: game.GameDataDirectory, GameID: int64(game.PK)}:
+		case <-sbF.ctx.Done():
+			return sbF.ctx.Err()
 		}
 
-		var awayDivisionName string
-		switch game.AwayDivision {
-		case "E":
-			awayDivisionName = "East"
-		case "C":
-			awayDivisionName = "Central"
-		case "W":
-			awayDivisionName = "West"
-		default:
-			fmt.Printf("Unexpected division code ecountered: %s", game.AwayDivision)
-			return
+		//Second, create all data records
+		err = sbF.sendGame(i, game)
+		if err != nil {
+			if sbF.ctx.Err() != nil {
+				return sbF.ctx.Err()
+			}
+			item := game.GameDataDirectory
+			if len(item) == 0 {
+				item = fmt.Sprintf("%s game %d", dataPath, i)
+			}
+			slog.Error("Unable to process a game of the scoreboard", "url", dataPath, "game", item, "err", err)
+			results.Fail(item, err)
 		}
+	}
+	return sbF.ctx.Err()
+}
 
-		var homeDivisionName string
-		switch game.HomeDivision {
-		case "E":
-			homeDivisionName = "East"
-		case "C":
-			homeDivisionName = "Central"
-		case "W":
-			homeDivisionName = "West"
-		default:
-			fmt.Printf("Unexpected division code ecountered: %s", game.HomeDivision)
-			return
-		}
+/*
+sendGame creates the records of a single game of the scoreboard and sends them
+	on.  Values that aren't in the catalog are recorded (see unknown) and the
+	game is still loaded; values that can't be parsed are an error.
+*/
+func (sbF *ScoreBoardFile) sendGame(i int, game ScoreboardXMLGame) error {
+	timeString := game.DateTime + game.AMPM
+	location, err := catalog.Location(game.TimeZone)
+	if errors.Is(err, catalog.ErrUnknown) {
+		sbF.unknown("time zone", game.TimeZone)
+		location, err = catalog.Location(catalog.DefaultTimeZone)
+	}
+	if err != nil {
+		return err
+	}
+	gameTime, err := time.ParseInLocation("2006/01/02 3:04PM", timeString, location)
+	if err != nil {
+		return fmt.Errorf("unable to parse the timestamp of game %d (%s): %w", i, timeString, err)
+	}
+	venueID, err := strconv.ParseInt(game.VenueID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("unable to parse the venue ID of game %d (%s): %w", i, game.VenueID, err)
+	}
+	awayLeagueID, awayLeagueName, err := sbF.league(game.AwayLeagueID)
+	if err != nil {
+		return fmt.Errorf("unable to parse the away league ID of game %d (%s): %w", i, game.AwayLeagueID, err)
+	}
+	homeLeagueID, homeLeagueName, err := sbF.league(game.HomeLeagueID)
+	if err != nil {
+		return fmt.Errorf("unable to parse the home league ID of game %d (%s): %w", i, game.HomeLeagueID, err)
+	}
+	awayDivisionName := sbF.division(game.AwayDivision)
+	homeDivisionName := sbF.division(game.HomeDivision)
 
-		awayTeamID, err := strconv.ParseInt(game.AwayTeamID, 10, 64)
-		if err != nil {
-			fmt.Printf("Unable to parse the away team ID of game %d (%s): %s", i, game.AwayTeamID, err.Error())
-			return
-		}
-		homeTeamID, err := strconv.ParseInt(game.HomeTeamID, 10, 64)
-		if err != nil {
-			fmt.Printf("Unable to parse the home team ID of game %d (%s): %s", i, game.HomeTeamID, err.Error())
-			return
-		}
+	awayTeamID, err := strconv.ParseInt(game.AwayTeamID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("unable to parse the away team ID of game %d (%s): %w", i, game.AwayTeamID, err)
+	}
+	homeTeamID, err := strconv.ParseInt(game.HomeTeamID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("unable to parse the home team ID of game %d (%s): %w", i, game.HomeTeamID, err)
+	}
 
-		var topOfInning bool
-		switch game.Status.TopInning {
-		case "Y":
-			topOfInning = true
-		case "N":
-			topOfInning = false
-		case "":
-			topOfInning = false
-		default:
-			fmt.Printf("Unexpected top of inning code encountered: %s", game.Status.TopInning)
-			return
-		}
+	topOfInning := sbF.flag("top of inning code", game.Status.TopInning)
+	perfectGame := sbF.flag("perfect game code", game.Status.Perfect)
+	noHitter := sbF.flag("no hitter code", game.Status.NoHitter)
+
+	venue := records.VenueRecord{
+		RecordName:    "VenueRecord",
+		EffectiveDate: gameTime,
+		ID:            venueID,
+		Name:          game.Venue,
+		Location:      game.Location,
+		Channel:       game.VenueWChanLoc,
+	}
+	awayLeague := records.LeagueRecord{
+		RecordName:    "LeagueRecord",
+		EffectiveDate: gameTime,
+		ID:            awayLeagueID,
+		Name:          awayLeagueName,
+		SportCode:     game.AwaySportCode,
+	}
+	homeLeague := records.LeagueRecord{
+		RecordName:    "LeagueRecord",
+		EffectiveDate: gameTime,
+		ID:            homeLeagueID,
+		Name:          homeLeagueName,
+		SportCode:     game.HomeSportCode,
+	}
+	awayDivision := records.DivisionRecord{
+		RecordName:    "DivisionRecord",
+		EffectiveDate: gameTime,
+		Name:          awayDivisionName,
+		Code:          game.AwayDivision,
+	}
+	homeDivision := records.DivisionRecord{
+		RecordName:    "DivisionRecord",
+		EffectiveDate: gameTime,
+		Name:          homeDivisionName,
+		Code:          game.HomeDivision,
+	}
+	awayTeam := records.TeamRecord{
+		RecordName:    "TeamRecord",
+		EffectiveDate: gameTime,
+		ID:            awayTeamID,
+		Name:          game.AwayTeamName,
+		Code:          game.AwayCode,
+		City:          game.AwayTeamCity,
+		LeagueID:      awayLeagueID,
+		Division:      game.AwayDivision,
+	}
+	homeTeam := records.TeamRecord{
+		RecordName:    "TeamRecord",
+		EffectiveDate: gameTime,
+		ID:            homeTeamID,
+		Name:          game.HomeTeamName,
+		Code:          game.HomeCode,
+		City:          game.HomeTeamCity,
+		LeagueID:      homeLeagueID,
+		Division:      game.HomeDivision,
+	}
+	awayStanding := records.StandingRecord{
+		RecordName:        "StandingRecord",
+		EffectiveDate:     gameTime,
+		TeamID:            awayTeamID,
+		Wins:              game.AwayWins,
+		Losses:            game.AwayLosses,
+		GamesBack:         game.AwayGamesBack,
+		WildcardGamesBack: game.AwayGamesBackWildcard,
+	}
+	homeStanding := records.StandingRecord{
+		RecordName:        "StandingRecord",
+		EffectiveDate:     gameTime,
+		TeamID:            homeTeamID,
+		Wins:              game.HomeWins,
+		Losses:            game.HomeLosses,
+		GamesBack:         game.HomeGamesBack,
+		WildcardGamesBack: game.HomeGamesBackWildcard,
+	}
+	gameRecord := records.GameRecord{
+		RecordName:       "GameRecord",
+		EffectiveDate:    gameTime,
+		ID:               int64(game.PK),
+		ResumeDate:       game.ResumeDate,
+		OriginalDate:     game.OriginalDate,
+		GameType:         game.GameType,
+		Tiebreaker:       game.TieBreakerSW,
+		GameDay:          game.GamedaySW,
+		DoubleHeader:     game.DoubleHeaderSW,
+		GameNumber:       game.GameNumber,
+		TBDFlag:          game.TBDFlag,
+		Interleague:      game.League,
+		ScheduledInnings: game.ScheduledInnings,
+		Description:      game.Description,
+		VenueID:          venueID,
+		AwayTeamID:       awayTeamID,
+		HomeTeamID:       homeTeamID,
+	}
 
-		var perfectGame bool
-		switch game.Status.Perfect {
-		case "Y":
-			perfectGame = true
-		case "N":
-			perfectGame = false
-		case "":
-			perfectGame = false
-		default:
-			fmt.Printf("Unexpected perfect game code encountered: %s", game.Status.Perfect)
-			return
-		}
+	gameStatus := records.GameStatusRecord{
+		RecordName:     "GameStatusRecord",
+		EffectiveDate:  gameTime,
+		ID:             int64(game.PK),
+		Status:         game.Status.Status,
+		Ind:            game.Status.Ind,
+		Reason:         game.Status.Reason,
+		CurrentInning:  game.Status.Inning,
+		TopOfInning:    topOfInning,
+		Balls:          game.Status.Balls,
+		Strikes:        game.Status.Strikes,
+		Outs:           game.Status.Outs,
+		InningState:    game.Status.InningState,
+		Note:           game.Status.Note,
+		PerfectGame:    perfectGame,
+		NoHitter:       noHitter,
+		AwayTeamRuns:   game.Linescore.Runs.Away,
+		HomeTeamRuns:   game.Linescore.Runs.Home,
+		AwayTeamHits:   game.Linescore.Hits.Away,
+		HomeTeamHits:   game.Linescore.Hits.Home,
+		AwayTeamErrors: game.Linescore.Errors.Away,
+		HomeTeamErrors: game.Linescore.Errors.Home,
+		AwayTeamHR:     game.Linescore.HR.Away,
+		HomeTeamHR:     game.Linescore.HR.Home,
+		AwayTeamSB:     game.Linescore.SB.Away,
+		HomeTeamSB:     game.Linescore.SB.Home,
+		AwayTeamSO:     game.Linescore.SO.Away,
+		HomeTeamSO:     game.Linescore.SO.Home,
+	}
 
-		var noHitter bool
-		switch game.Status.NoHitter {
-		case "Y":
-			noHitter = true
-		case "N":
-			noHitter = false
-		case "":
-			noHitter = false
-		default:
-			fmt.Printf("Unexpected no hitter code encountered: %s", game.Status.NoHitter)
-			return
-		}
+	sbF.statuses[gameStatus.ID] = gameStatus.Status
 
-		venues[i] = records.VenueRecord{
-			RecordName:    "VenueRecord",
-			EffectiveDate: gameTime,
-			ID:            venueID,
-			Name:          game.Venue,
-			Location:      game.Location,
-			Channel:       game.VenueWChanLoc,
-		}
-		leagues[i*2] = records.LeagueRecord{
-			RecordName:    "LeagueRecord",
-			EffectiveDate: gameTime,
-			ID:            awayLeagueID,
-			Name:          awayLeagueName,
-			SportCode:     game.AwaySportCode,
-		}
-		leagues[i*2+1] = records.LeagueRecord{
-			RecordName:    "LeagueRecord",
+	gameStatus.Innings = make([]records.InningScoreRecord, len(game.Linescore.Innings))
+	for y, gameInning := range game.Linescore.Innings {
+		gameStatus.Innings[y] = records.InningScoreRecord{
+			RecordName:    "InningScoreRecord",
 			EffectiveDate: gameTime,
-			ID:            homeLeagueID,
-			Name:          homeLeagueName,
-			SportCode:     game.HomeSportCode,
+			GameID:        int64(game.PK),
+			Inning:        y + 1,
+			AwayTeamRuns:  gameInning.Away,
+			HomeTeamRuns:  gameInning.Home,
 		}
-		divisions[i*2] = records.DivisionRecord{
-			RecordName:    "DivisionRecord",
-			EffectiveDate: gameTime,
-			Name:          awayDivisionName,
-			Code:          game.AwayDivision,
-		}
-		divisions[i*2+1] = records.DivisionRecord{
-			RecordName:    "DivisionRecord",
-			EffectiveDate: gameTime,
-			Name:          homeDivisionName,
-			Code:          game.HomeDivision,
-		}
-		teams[i*2] = records.TeamRecord{
-			RecordName:    "TeamRecord",
-			EffectiveDate: gameTime,
-			ID:            awayTeamID,
-			Name:          game.AwayTeamName,
-			Code:          game.AwayCode,
-			City:          game.AwayTeamCity,
-			LeagueID:      awayLeagueID,
-			Division:      game.AwayDivision,
-		}
-		teams[i*2+1] = records.TeamRecord{
-			RecordName:    "TeamRecord",
-			EffectiveDate: gameTime,
-			ID:            homeTeamID,
-			Name:          game.HomeTeamName,
-			Code:          game.HomeCode,
-			City:          game.HomeTeamCity,
-			LeagueID:      homeLeagueID,
-			Division:      game.HomeDivision,
-		}
-		standings[i*2] = records.StandingRecord{
-			RecordName:        "StandingRecord",
-			EffectiveDate:     gameTime,
-			TeamID:            awayTeamID,
-			Wins:              game.AwayWins,
-			Losses:            game.AwayLosses,
-			GamesBack:         game.AwayGamesBack,
-			WildcardGamesBack: game.AwayGamesBackWildcard,
-		}
-		standings[i*2+1] = records.StandingRecord{
-			RecordName:        "StandingRecord",
-			EffectiveDate:     gameTime,
-			TeamID:            homeTeamID,
-			Wins:              game.HomeWins,
-			Losses:            game.HomeLosses,
-			GamesBack:         game.HomeGamesBack,
-			WildcardGamesBack: game.HomeGamesBackWildcard,
+	}
+
+	sbF.sendJSONToOutput(json.Marshal(venue))
+	sbF.sendJSONToOutput(json.Marshal(awayLeague))
+	sbF.sendJSONToOutput(json.Marshal(homeLeague))
+	sbF.sendJSONToOutput(json.Marshal(awayDivision))
+	sbF.sendJSONToOutput(json.Marshal(homeDivision))
+	sbF.sendJSONToOutput(json.Marshal(awayTeam))
+	sbF.sendJSONToOutput(json.Marshal(homeTeam))
+	sbF.sendJSONToOutput(json.Marshal(awayStanding))
+	sbF.sendJSONToOutput(json.Marshal(homeStanding))
+	sbF.sendJSONToOutput(json.Marshal(gameRecord))
+	sbF.sendJSONToOutput(json.Marshal(gameStatus))
+	for _, isR := range gameStatus.Innings {
+		sbF.sendJSONToOutput(json.Marshal(isR))
+	}
+
+	probables, err := game.probablePitchers(gameTime, awayTeamID, homeTeamID)
+	if err != nil {
+		return fmt.Errorf("unable to parse the probable pitchers of game %d: %w", i, err)
+	}
+	for _, ppR := range probables {
+		sbF.sendJSONToOutput(json.Marshal(ppR))
+	}
+	decisions, err := game.pitcherDecisions(gameTime)
+	if err != nil {
+		return fmt.Errorf("unable to parse the pitcher decisions of game %d: %w", i, err)
+	}
+	for _, pdR := range decisions {
+		sbF.sendJSONToOutput(json.Marshal(pdR))
+	}
+	return nil
+}
+
+/*
+unknown records a value of the scoreboard that isn't in the catalog
+*/
+func (sbF *ScoreBoardFile) unknown(kind, value string) {
+	slog.Warn("Unknown value in the scoreboard", "kind", kind, "value", value)
+	summary.FromContext(sbF.ctx).Add(summary.Unknown(kind, value), 1)
+}
+
+/*
+league parses a league ID and finds the name of the league.  A team without a
+	league, such as a college team in spring training, has an ID of zero.
+*/
+func (sbF *ScoreBoardFile) league(value string) (int64, string, error) {
+	if len(value) == 0 {
+		return 0, "", nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, "", err
+	}
+	league, ok := catalog.LookupLeague(id)
+	if ok == false {
+		sbF.unknown("league", value)
+	}
+	return id, league.Name, nil
+}
+
+/*
+division finds the name of a division code.  Teams outside of a division have
+	an empty code, which is not recorded as unknown.
+*/
+func (sbF *ScoreBoardFile) division(code string) string {
+	if len(code) == 0 {
+		return ""
+	}
+	division, ok := catalog.LookupDivision(code)
+	if ok == false {
+		sbF.unknown("division", code)
+	}
+	return division.Name
+}
+
+/*
+flag reads a Y/N code of the scoreboard, where empty means N
+*/
+func (sbF *ScoreBoardFile) flag(kind, value string) bool {
+	switch value {
+	case "Y":
+		return true
+	case "N", "":
+		return false
+	}
+	sbF.unknown(kind, value)
+	return false
+}
+
+/*
+probablePitchers returns the probable pitcher of each team that has one
+*/
+func (game *ScoreboardXMLGame) probablePitchers(effectiveDate time.Time, awayTeamID, homeTeamID int64) ([]records.ProbablePitcherRecord, error) {
+	var probables []records.ProbablePitcherRecord
+	for _, probable := range []struct {
+		TeamID  int64
+		Pitcher ScoreboardXMLPitcher
+	}{{awayTeamID, game.AwayProbablePitcher}, {homeTeamID, game.HomeProbablePitcher}} {
+		if len(probable.Pitcher.ID) == 0 {
+			continue
 		}
-		games[i] = records.GameRecord{
-			RecordName:       "GameRecord",
-			EffectiveDate:    gameTime,
-			ID:               int64(game.PK),
-			ResumeDate:       game.ResumeDate,
-			OriginalDate:     game.OriginalDate,
-			GameType:         game.GameType,
-			Tiebreaker:       game.TieBreakerSW,
-			GameDay:          game.GamedaySW,
-			DoubleHeader:     game.DoubleHeaderSW,
-			GameNumber:       game.GameNumber,
-			TBDFlag:          game.TBDFlag,
-			Interleague:      game.League,
-			ScheduledInnings: game.ScheduledInnings,
-			Description:      game.Description,
-			VenueID:          venueID,
-			AwayTeamID:       awayTeamID,
-			HomeTeamID:       homeTeamID,
+		playerID, err := strconv.ParseInt(probable.Pitcher.ID, 10, 64)
+		if err != nil {
+			return nil, err
 		}
+		probables = append(probables, records.ProbablePitcherRecord{
+			RecordName:    "ProbablePitcherRecord",
+			EffectiveDate: effectiveDate,
+			GameID:        int64(game.PK),
+			TeamID:        probable.TeamID,
+			PlayerID:      playerID,
+			FirstName:     probable.Pitcher.FirstName,
+			LastName:      probable.Pitcher.LastName,
+			Number:        probable.Pitcher.Number,
+			Throws:        probable.Pitcher.ThrowingHand,
+			Wins:          scoreboardCount(probable.Pitcher.Wins),
+			Losses:        scoreboardCount(probable.Pitcher.Losses),
+			ERA:           probable.Pitcher.ERA,
+		})
+	}
+	return probables, nil
+}
 
-		gameStatuses[i] = records.GameStatusRecord{
-			RecordName:     "GameStatusRecord",
-			EffectiveDate:  gameTime,
-			ID:             int64(game.PK),
-			Status:         game.Status.Status,
-			Ind:            game.Status.Ind,
-			Reason:         game.Status.Reason,
-			CurrentInning:  game.Status.Inning,
-			TopOfInning:    topOfInning,
-			Balls:          game.Status.Balls,
-			Strikes:        game.Status.Strikes,
-			Outs:           game.Status.Outs,
-			InningState:    game.Status.InningState,
-			Note:           game.Status.Note,
-			PerfectGame:    perfectGame,
-			NoHitter:       noHitter,
-			AwayTeamRuns:   game.Linescore.Runs.Away,
-			HomeTeamRuns:   game.Linescore.Runs.Home,
-			AwayTeamHits:   game.Linescore.Hits.Away,
-			HomeTeamHits:   game.Linescore.Hits.Home,
-			AwayTeamErrors: game.Linescore.Errors.Away,
-			HomeTeamErrors: game.Linescore.Errors.Home,
-			AwayTeamHR:     game.Linescore.HR.Away,
-			HomeTeamHR:     game.Linescore.HR.Home,
-			AwayTeamSB:     game.Linescore.SB.Away,
-			HomeTeamSB:     game.Linescore.SB.Home,
-			AwayTeamSO:     game.Linescore.SO.Away,
-			HomeTeamSO:     game.Linescore.SO.Home,
+/*
+pitcherDecisions returns the winning, losing and save pitchers that a game has
+*/
+func (game *ScoreboardXMLGame) pitcherDecisions(effectiveDate time.Time) ([]records.PitcherDecisionRecord, error) {
+	var decisions []records.PitcherDecisionRecord
+	for _, decision := range []struct {
+		Decision string
+		Pitcher  ScoreboardXMLPitcher
+	}{{"W", game.WinningPitcher}, {"L", game.LosingPitcher}, {"S", game.SavePitcher}} {
+		if len(decision.Pitcher.ID) == 0 {
+			continue
 		}
-
-		gameStatuses[i].Innings = make([]records.InningScoreRecord, len(game.Linescore.Innings))
-		for y, gameInning := range game.Linescore.Innings {
-			gameStatuses[i].Innings[y] = records.InningScoreRecord{
-				RecordName:    "InningScoreRecord",
-				EffectiveDate: gameTime,
-				GameID:        int64(game.PK),
-				Inning:        y + 1,
-				AwayTeamRuns:  gameInning.Away,
-				HomeTeamRuns:  gameInning.Home,
-			}
+		playerID, err := strconv.ParseInt(decision.Pitcher.ID, 10, 64)
+		if err != nil {
+			return nil, err
 		}
+		decisions = append(decisions, records.PitcherDecisionRecord{
+			RecordName:        "PitcherDecisionRecord",
+			EffectiveDate:     effectiveDate,
+			GameID:            int64(game.PK),
+			Decision:          decision.Decision,
+			PlayerID:          playerID,
+			FirstName:         decision.Pitcher.First,
+			LastName:          decision.Pitcher.Last,
+			Wins:              scoreboardCount(decision.Pitcher.Wins),
+			Losses:            scoreboardCount(decision.Pitcher.Losses),
+			Saves:             scoreboardCount(decision.Pitcher.Saves),
+			SaveOpportunities: scoreboardCount(decision.Pitcher.SaveOpportunities),
+			ERA:               decision.Pitcher.ERA,
+		})
+	}
+	return decisions, nil
+}
 
-		sbF.sendJSONToOutput(json.Marshal(venues[i]))
-		sbF.sendJSONToOutput(json.Marshal(leagues[i*2]))
-		sbF.sendJSONToOutput(json.Marshal(leagues[i*2+1]))
-		sbF.sendJSONToOutput(json.Marshal(divisions[i*2]))
-		sbF.sendJSONToOutput(json.Marshal(divisions[i*2+1]))
-		sbF.sendJSONToOutput(json.Marshal(teams[i*2]))
-		sbF.sendJSONToOutput(json.Marshal(teams[i*2+1]))
-		sbF.sendJSONToOutput(json.Marshal(standings[i*2]))
-		sbF.sendJSONToOutput(json.Marshal(standings[i*2+1]))
-		sbF.sendJSONToOutput(json.Marshal(games[i]))
-		sbF.sendJSONToOutput(json.Marshal(gameStatuses[i]))
-		for _, isR := range gameStatuses[i].Innings {
-			sbF.sendJSONToOutput(json.Marshal(isR))
-		}
+/*
+scoreboardCount reads a count such as wins or saves, which the scoreboard
+	leaves empty when it doesn't have one
+*/
+func scoreboardCount(value string) int {
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
 	}
+	return count
 }
 
 func (sbF *ScoreBoardFile) sendJSONToOutput(rep []byte, err error) {
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("Unable to encode record", "err", err)
 		return
 	}
-	sbF.DataOutput <- string(rep)
+	select {
+	case sbF.DataOutput <- string(rep):
+	case <-sbF.ctx.Done():
+	}
 }
@@ -0,0 +1,219 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pipelinestage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bauer312/baseball/pkg/fixtures"
+	"github.com/bauer312/baseball/pkg/records"
+	"github.com/bauer312/baseball/pkg/summary"
+)
+
+func TestScoreBoardFilePitchers(t *testing.T) {
+	ts := httptest.NewServer(fixtures.New(fixtures.Default()))
+	defer ts.Close()
+
+	sbF := ScoreBoardFile{
+		DataInput: make(chan string),
+		Client:    http.DefaultClient,
+	}
+	sbF.Init(context.Background())
+	go sbF.Run()
+	go func() {
+		for range sbF.GameFileOutout {
+		}
+	}()
+
+	decisions := make(map[string]records.PitcherDecisionRecord)
+	probables := make(map[int64]records.ProbablePitcherRecord)
+	done := make(chan bool)
+	go func() {
+		for data := range sbF.DataOutput {
+			name, _ := records.Name(data)
+			switch name {
+			case "PitcherDecisionRecord":
+				var pdR records.PitcherDecisionRecord
+				json.Unmarshal([]byte(data), &pdR)
+				decisions[pdR.Decision] = pdR
+			case "ProbablePitcherRecord":
+				var ppR records.ProbablePitcherRecord
+				json.Unmarshal([]byte(data), &ppR)
+				probables[ppR.TeamID] = ppR
+			}
+		}
+		done <- true
+	}()
+
+	sbF.DataInput <- ts.URL + "/components/game/mlb/year_2019/month_06/day_11/"
+	sbF.Stop()
+	close(sbF.GameFileOutout)
+	close(sbF.DataOutput)
+	<-done
+
+	var decisionTest = []struct {
+		Decision string
+		PlayerID int64
+		LastName string
+		Wins     int
+		Losses   int
+		Saves    int
+		ERA      string
+	}{
+		{"W", 543037, "Cole", 7, 2, 0, "3.67"},
+		{"L", 519242, "Sale", 3, 8, 0, "4.40"},
+		{"S", 547973, "Chapman", 2, 1, 17, "1.91"},
+	}
+	if len(decisions) != len(decisionTest) {
+		t.Errorf("Expected %d pitcher decisions, received %d", len(decisionTest), len(decisions))
+	}
+	for _, ex := range decisionTest {
+		pdR := decisions[ex.Decision]
+		if pdR.GameID != 565012 || pdR.PlayerID != ex.PlayerID || pdR.LastName != ex.LastName || pdR.Wins != ex.Wins ||
+			pdR.Losses != ex.Losses || pdR.Saves != ex.Saves || pdR.ERA != ex.ERA {
+			t.Errorf("Unexpected %s decision: %+v", ex.Decision, pdR)
+		}
+	}
+	if decisions["S"].SaveOpportunities != 19 {
+		t.Errorf("Expected 19 save opportunities, received %d", decisions["S"].SaveOpportunities)
+	}
+
+	if len(probables) != 2 {
+		t.Fatalf("Expected 2 probable pitchers, received %d", len(probables))
+	}
+	if ppR := probables[147]; ppR.PlayerID != 543037 || ppR.FirstName != "Gerrit" || ppR.Throws != "RHP" || ppR.Wins != 6 {
+		t.Errorf("Unexpected away probable pitcher: %+v", ppR)
+	}
+	if ppR := probables[111]; ppR.PlayerID != 519242 || ppR.LastName != "Sale" || ppR.ERA != "4.52" {
+		t.Errorf("Unexpected home probable pitcher: %+v", ppR)
+	}
+
+	if scoreboardCount("") != 0 || scoreboardCount("12") != 12 {
+		t.Errorf("Unexpected count from the scoreboard")
+	}
+}
+
+func TestScoreBoardFileUnknownValues(t *testing.T) {
+	game := `<game game_pk="%d" game_data_directory="/components/game/aaa/year_2019/month_06/day_10/gid_%s" time_date="2019/06/10 7:05" ampm="PM" time_zone="%s" venue_id="%s"
+		away_team_id="1" away_league_id="%s" away_division="%s" away_sport_code="aaa"
+		home_team_id="2" home_league_id="117" home_division="N" home_sport_code="aaa">
+		<status status="Final" top_inning="%s"/></game>`
+	scoreboard := `<games year="2019" month="06" day="10">` +
+		fmt.Sprintf(game, 1, "2019_06_10_aaaaaa_bbbaaa_1", "CT", "10", "112", "S", "N") +
+		fmt.Sprintf(game, 2, "2019_06_10_cccaaa_dddaaa_1", "XT", "11", "999", "Z", "?") +
+		fmt.Sprintf(game, 3, "2019_06_10_eeeaaa_fffaaa_1", "ET", "bad", "117", "N", "N") +
+		fmt.Sprintf(game, 4, "2019_06_10_gggaaa_hhhaaa_1", "ET", "12", "", "", "Y") +
+		`</games>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, scoreboard)
+	}))
+	defer ts.Close()
+
+	results := summary.New("pipeline")
+	sbF := ScoreBoardFile{
+		DataInput: make(chan string),
+		Client:    http.DefaultClient,
+	}
+	sbF.Init(summary.NewContext(context.Background(), results))
+	go sbF.Run()
+	directories := 0
+	directoriesDone := make(chan bool)
+	go func() {
+		for range sbF.GameFileOutout {
+			directories++
+		}
+		close(directoriesDone)
+	}()
+
+	games := make(map[int64]records.GameRecord)
+	leagues := make(map[int64]string)
+	done := make(chan bool)
+	go func() {
+		for data := range sbF.DataOutput {
+			name, _ := records.Name(data)
+			switch name {
+			case "GameRecord":
+				var gR records.GameRecord
+				json.Unmarshal([]byte(data), &gR)
+				games[gR.ID] = gR
+			case "LeagueRecord":
+				var lR records.LeagueRecord
+				json.Unmarshal([]byte(data), &lR)
+				leagues[lR.ID] = lR.Name
+			}
+		}
+		done <- true
+	}()
+
+	sbF.DataInput <- ts.URL + "/components/game/aaa/year_2019/month_06/day_10/"
+	sbF.Stop()
+	close(sbF.GameFileOutout)
+	close(sbF.DataOutput)
+	<-done
+	<-directoriesDone
+
+	if directories != 4 {
+		t.Errorf("Expected 4 game directories, received %d", directories)
+	}
+	if len(games) != 3 || games[3].ID != 0 {
+		t.Errorf("Expected games 1, 2 and 4, received %v", games)
+	}
+	if gR := games[1]; gR.EffectiveDate.Format("15:04 -0700") != "19:05 -0500" {
+		t.Errorf("Expected game 1 to start at 19:05 Central time, received %s", gR.EffectiveDate.Format("15:04 -0700"))
+	}
+	if gR := games[2]; gR.EffectiveDate.Format("15:04 -0700") != "19:05 -0400" {
+		t.Errorf("Expected game 2 to fall back on Eastern time, received %s", gR.EffectiveDate.Format("15:04 -0700"))
+	}
+
+	var leagueTest = []struct {
+		ID   int64
+		Name string
+	}{
+		{112, "Pacific Coast League"},
+		{117, "International League"},
+		{999, ""},
+		{0, ""},
+	}
+	for _, ex := range leagueTest {
+		if name, ok := leagues[ex.ID]; ok == false || name != ex.Name {
+			t.Errorf("Expected league %d to be named %q, received %q", ex.ID, ex.Name, name)
+		}
+	}
+
+	var unknownTest = []struct {
+		Kind  string
+		Value string
+	}{
+		{"time zone", "XT"},
+		{"league", "999"},
+		{"division", "Z"},
+		{"top of inning code", "?"},
+	}
+	for _, ex := range unknownTest {
+		if count := results.Count(summary.Unknown(ex.Kind, ex.Value)); count != 1 {
+			t.Errorf("Expected the %s %s to be recorded once, received %d", ex.Kind, ex.Value, count)
+		}
+	}
+	if results.Failed() != 1 {
+		t.Errorf("Expected the game with a bad venue ID to fail, received %d failures", results.Failed())
+	}
+}
@@ -16,12 +16,18 @@
 
 package pipelinestage
 
+import "context"
+
 /*
-Controller is the interface that defines the methods for controlling a pipeline
+Controller is the interface that defines the methods for controlling a pipeline.
+	Init is given the context the stage runs under; cancelling it, or calling
+	Abort, makes Run give up on any outstanding work and return.  Stop closes
+	the input channel of the stage and waits for it to drain, so it must only
+	be called once the stage upstream has stopped sending.
 */
 type Controller interface {
 	Stop()
-	Init() error
+	Init(context.Context) error
 	Abort()
 	Run()
 }
@@ -17,6 +17,7 @@
 package pipelinestage
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
@@ -28,12 +29,15 @@ ScreenOutput contains the elements of a pipeline stage that will accept
 type ScreenOutput struct {
 	DataInput []chan string
 	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 /*
 Init the pipeline stage,
 */
-func (sO *ScreenOutput) Init() error {
+func (sO *ScreenOutput) Init(ctx context.Context) error {
+	sO.ctx, sO.cancel = context.WithCancel(ctx)
 	numChannels := len(sO.DataInput)
 	sO.wg.Add(numChannels)
 	return nil
@@ -47,15 +51,14 @@ func (sO *ScreenOutput) Stop() {
 		close(channel)
 	}
 	sO.wg.Wait()
+	sO.cancel()
 }
 
 /*
 Abort the pipeline stage immediately
 */
 func (sO *ScreenOutput) Abort() {
-	for _, channel := range sO.DataInput {
-		close(channel)
-	}
+	sO.cancel()
 }
 
 /*
@@ -69,7 +72,15 @@ func (sO *ScreenOutput) Run() {
 
 func (sO *ScreenOutput) runChannelInput(input chan string) {
 	defer sO.wg.Done()
-	for inputData := range input {
-		fmt.Println(inputData)
+	for {
+		select {
+		case inputData, ok := <-input:
+			if ok == false {
+				return
+			}
+			fmt.Println(inputData)
+		case <-sO.ctx.Done():
+			return
+		}
 	}
 }
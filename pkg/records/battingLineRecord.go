@@ -0,0 +1,153 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+BattingLineRecord is the batting line of a single player in a game, as it
+	appears in the boxscore
+*/
+type BattingLineRecord struct {
+	RecordName    string
+	EffectiveDate time.Time
+	GameID        int64
+	PlayerID      int64
+	TeamID        int64
+	Name          string
+	Position      string
+	BattingOrder  int
+	AtBats        int
+	Runs          int
+	Hits          int
+	RBI           int
+	Walks         int
+	Strikeouts    int
+	HomeRuns      int
+	StolenBases   int
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (blR *BattingLineRecord) ScreenOutput() {
+	fmt.Println(blR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (blR *BattingLineRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%d|%d|%s|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d\n",
+		blR.EffectiveDate.Format(time.UnixDate),
+		blR.GameID,
+		blR.PlayerID,
+		blR.TeamID,
+		blR.Name,
+		blR.Position,
+		blR.BattingOrder,
+		blR.AtBats,
+		blR.Runs,
+		blR.Hits,
+		blR.RBI,
+		blR.Walks,
+		blR.Strikeouts,
+		blR.HomeRuns,
+		blR.StolenBases,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (blR *BattingLineRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS BattingLineRecord (
+		effectiveDate 	timestamp with time zone,
+		gameid			bigint,
+		playerid		bigint,
+		teamid			bigint,
+		name			varchar(128),
+		position		varchar(8),
+		battingorder	int,
+		atbats			int,
+		runs			int,
+		hits			int,
+		rbi				int,
+		walks			int,
+		strikeouts		int,
+		homeruns		int,
+		stolenbases		int,
+		PRIMARY KEY (gameid, playerid)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (blR *BattingLineRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If this is a unique record, insert it.
+		2.  If this is a duplicate record and the effective date is later,
+				update the existing record.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	statement := `INSERT INTO BattingLineRecord VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15);`
+	_, err = db.ExecContext(ctx, statement, blR.EffectiveDate.UTC(), blR.GameID, blR.PlayerID, blR.TeamID,
+		blR.Name, blR.Position, blR.BattingOrder, blR.AtBats, blR.Runs, blR.Hits, blR.RBI, blR.Walks,
+		blR.Strikeouts, blR.HomeRuns, blR.StolenBases)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM BattingLineRecord WHERE
+			gameid=$1 AND playerid=$2;`
+			err = db.QueryRowContext(ctx, statement, blR.GameID, blR.PlayerID).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if blR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so update the record in the database
+				statement = `UPDATE BattingLineRecord SET effectiveDate=$1, teamid=$2, name=$3, position=$4,
+				battingorder=$5, atbats=$6, runs=$7, hits=$8, rbi=$9, walks=$10, strikeouts=$11, homeruns=$12,
+				stolenbases=$13 WHERE gameid=$14 AND playerid=$15;`
+				_, err := db.ExecContext(ctx, statement, blR.EffectiveDate.UTC(), blR.TeamID, blR.Name, blR.Position,
+					blR.BattingOrder, blR.AtBats, blR.Runs, blR.Hits, blR.RBI, blR.Walks, blR.Strikeouts,
+					blR.HomeRuns, blR.StolenBases, blR.GameID, blR.PlayerID)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,12 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -56,7 +56,7 @@ func (dR *DivisionRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (dR *DivisionRecord) CreateTable(db *sql.DB) {
+func (dR *DivisionRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS DivisionRecord (
 		effectiveDate 	timestamp with time zone,
 		name 			varchar(128),
@@ -64,10 +64,7 @@ func (dR *DivisionRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (name, code)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -76,56 +73,40 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (dR *DivisionRecord) UpdateRecord(db *sql.DB) {
+func (dR *DivisionRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 		2.  If this is a duplicate record and the effective date is earlier,
 				update the existing record.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO DivisionRecord VALUES ($1,$2,$3);`
-	_, err = db.Exec(statement, dR.EffectiveDate.UTC(), dR.Name, dR.Code)
+	statement := `INSERT INTO DivisionRecord VALUES ($1,$2,$3);`
+	_, err = db.ExecContext(ctx, statement, dR.EffectiveDate.UTC(), dR.Name, dR.Code)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingEffectiveDate time.Time
-				statement = `SELECT effectiveDate FROM DivisionRecord WHERE
-				name=$1 AND code=$2;`
-				err = db.QueryRow(statement, dR.Name, dR.Code).Scan(&existingEffectiveDate)
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM DivisionRecord WHERE
+			name=$1 AND code=$2;`
+			err = db.QueryRowContext(ctx, statement, dR.Name, dR.Code).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if existingEffectiveDate.Sub(dR.EffectiveDate.UTC()) > 0 {
+				//The new date is before the existing date, so update the record in the database
+				//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, dR.EffectiveDate)
+				statement = `UPDATE DivisionRecord SET effectiveDate=$1 WHERE
+				name=$2 AND code=$3;`
+				_, err := db.ExecContext(ctx, statement, dR.EffectiveDate.UTC(), dR.Name, dR.Code)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if existingEffectiveDate.Sub(dR.EffectiveDate.UTC()) > 0 {
-					//The new date is before the existing date, so update the record in the database
-					//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, dR.EffectiveDate)
-					statement = `UPDATE DivisionRecord SET effectiveDate=$1 WHERE
-					name=$2 AND code=$3;`
-					_, err := db.Exec(statement, dR.EffectiveDate.UTC(), dR.Name, dR.Code)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
@@ -17,12 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -82,7 +82,7 @@ func (gR *GameRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (gR *GameRecord) CreateTable(db *sql.DB) {
+func (gR *GameRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS GameRecord (
 		effectiveDate 		timestamp with time zone,
 		id 					bigint,
@@ -103,10 +103,7 @@ func (gR *GameRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (id)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -115,61 +112,45 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (gR *GameRecord) UpdateRecord(db *sql.DB) {
+func (gR *GameRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 		2.  If this is a duplicate record and the effective date is later,
 				update the existing record.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO GameRecord VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16);`
-	_, err = db.Exec(statement, gR.EffectiveDate.UTC(), gR.ID, gR.ResumeDate, gR.OriginalDate, gR.GameType,
+	statement := `INSERT INTO GameRecord VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16);`
+	_, err = db.ExecContext(ctx, statement, gR.EffectiveDate.UTC(), gR.ID, gR.ResumeDate, gR.OriginalDate, gR.GameType,
 		gR.Tiebreaker, gR.GameDay, gR.DoubleHeader, gR.GameNumber, gR.TBDFlag, gR.Interleague,
 		gR.ScheduledInnings, gR.Description, gR.VenueID, gR.AwayTeamID, gR.HomeTeamID)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingEffectiveDate time.Time
-				statement = `SELECT effectiveDate FROM GameRecord WHERE id=$1;`
-				err = db.QueryRow(statement, gR.ID).Scan(&existingEffectiveDate)
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM GameRecord WHERE id=$1;`
+			err = db.QueryRowContext(ctx, statement, gR.ID).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if gR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so update the record in the database
+				//fmt.Printf("Existing: %v New: %v --> Replacing record in DB\n", existingEffectiveDate, gR.EffectiveDate)
+				statement = `UPDATE GameRecord SET effectiveDate=$1, resumedate=$2, originaldate=$3,
+				gametype=$4, tiebreaker=$5, gameday=$6, doubleheader=$7, gamenumber=$8, tbdflag=$9,
+				interleague=$10, scheduledinnings=$11, description=$12, venueid=$13, awayteamid=$14,
+				hometeamid=$15 WHERE id=$16;`
+				_, err := db.ExecContext(ctx, statement, gR.EffectiveDate.UTC(), gR.ResumeDate, gR.OriginalDate, gR.GameType,
+					gR.Tiebreaker, gR.GameDay, gR.DoubleHeader, gR.GameNumber, gR.TBDFlag, gR.Interleague,
+					gR.ScheduledInnings, gR.Description, gR.VenueID, gR.AwayTeamID, gR.HomeTeamID, gR.ID)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if gR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
-					//The new date is after the existing date, so update the record in the database
-					//fmt.Printf("Existing: %v New: %v --> Replacing record in DB\n", existingEffectiveDate, gR.EffectiveDate)
-					statement = `UPDATE GameRecord SET effectiveDate=$1, resumedate=$2, originaldate=$3,
-					gametype=$4, tiebreaker=$5, gameday=$6, doubleheader=$7, gamenumber=$8, tbdflag=$9,
-					interleague=$10, scheduledinnings=$11, description=$12, venueid=$13, awayteamid=$14,
-					hometeamid=$15 WHERE id=$16;`
-					_, err := db.Exec(statement, gR.EffectiveDate.UTC(), gR.ResumeDate, gR.OriginalDate, gR.GameType,
-						gR.Tiebreaker, gR.GameDay, gR.DoubleHeader, gR.GameNumber, gR.TBDFlag, gR.Interleague,
-						gR.ScheduledInnings, gR.Description, gR.VenueID, gR.AwayTeamID, gR.HomeTeamID, gR.ID)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
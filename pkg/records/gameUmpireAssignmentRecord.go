@@ -0,0 +1,117 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+GameUmpireAssignmentRecord is the umpire working a position in a game.  The
+	position is HP, 1B, 2B or 3B, or LF and RF for the postseason.  The
+	plate umpire of a pitch in mlb_savant or mlb_gameday is the one at HP
+	with the same gameid (game_pk).
+*/
+type GameUmpireAssignmentRecord struct {
+	RecordName    string
+	EffectiveDate time.Time
+	GameID        int64
+	Position      string
+	UmpireID      int64
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (guaR *GameUmpireAssignmentRecord) ScreenOutput() {
+	fmt.Println(guaR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (guaR *GameUmpireAssignmentRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%s|%d\n",
+		guaR.EffectiveDate.Format(time.UnixDate),
+		guaR.GameID,
+		guaR.Position,
+		guaR.UmpireID,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (guaR *GameUmpireAssignmentRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS GameUmpireAssignmentRecord (
+		effectiveDate 	timestamp with time zone,
+		gameid			bigint,
+		position		varchar(8),
+		umpireid		bigint,
+		PRIMARY KEY (gameid, position)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (guaR *GameUmpireAssignmentRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If this is a unique record, insert it.
+		2.  If this is a duplicate record and the effective date is later,
+				replace the existing record.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	statement := `INSERT INTO GameUmpireAssignmentRecord VALUES ($1,$2,$3,$4);`
+	_, err = db.ExecContext(ctx, statement, guaR.EffectiveDate.UTC(), guaR.GameID, guaR.Position, guaR.UmpireID)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM GameUmpireAssignmentRecord WHERE
+			gameid=$1 AND position=$2;`
+			err = db.QueryRowContext(ctx, statement, guaR.GameID, guaR.Position).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if guaR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so replace the record in the database
+				statement = `UPDATE GameUmpireAssignmentRecord SET effectiveDate=$1, umpireid=$2 WHERE
+				gameid=$3 AND position=$4;`
+				_, err := db.ExecContext(ctx, statement, guaR.EffectiveDate.UTC(), guaR.UmpireID, guaR.GameID, guaR.Position)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
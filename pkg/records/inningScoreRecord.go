@@ -17,12 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -60,7 +60,7 @@ func (isR *InningScoreRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (isR *InningScoreRecord) CreateTable(db *sql.DB) {
+func (isR *InningScoreRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS InningScoreRecord (
 		effectiveDate 	timestamp with time zone,
 		gameid			bigint,
@@ -70,10 +70,7 @@ func (isR *InningScoreRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (gameid, inning)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -82,56 +79,40 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (isR *InningScoreRecord) UpdateRecord(db *sql.DB) {
+func (isR *InningScoreRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 		2.  If this is a duplicate record and the effective date is later,
 				replace the existing record.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO InningScoreRecord VALUES ($1,$2,$3,$4,$5);`
-	_, err = db.Exec(statement, isR.EffectiveDate.UTC(), isR.GameID, isR.Inning, isR.AwayTeamRuns, isR.HomeTeamRuns)
+	statement := `INSERT INTO InningScoreRecord VALUES ($1,$2,$3,$4,$5);`
+	_, err = db.ExecContext(ctx, statement, isR.EffectiveDate.UTC(), isR.GameID, isR.Inning, isR.AwayTeamRuns, isR.HomeTeamRuns)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingEffectiveDate time.Time
-				statement = `SELECT effectiveDate FROM InningScoreRecord WHERE
-				gameid=$1 AND inning=$2;`
-				err = db.QueryRow(statement, isR.GameID, isR.Inning).Scan(&existingEffectiveDate)
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM InningScoreRecord WHERE
+			gameid=$1 AND inning=$2;`
+			err = db.QueryRowContext(ctx, statement, isR.GameID, isR.Inning).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if isR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so replace the record in the database
+				//fmt.Printf("Existing: %v New: %v --> Replacing record in DB\n", existingEffectiveDate, isR.EffectiveDate)
+				statement = `UPDATE InningScoreRecord SET effectiveDate=$1, awayteamruns=$2, hometeamruns=$3 WHERE
+				gameid=$4 AND inning=$5;`
+				_, err := db.ExecContext(ctx, statement, isR.EffectiveDate.UTC(), isR.AwayTeamRuns, isR.HomeTeamRuns, isR.GameID, isR.Inning)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if isR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
-					//The new date is after the existing date, so replace the record in the database
-					//fmt.Printf("Existing: %v New: %v --> Replacing record in DB\n", existingEffectiveDate, isR.EffectiveDate)
-					statement = `UPDATE InningScoreRecord SET effectiveDate=$1, awayteamruns=$2, hometeamruns=$3 WHERE
-					gameid=$4 AND inning=$5;`
-					_, err := db.Exec(statement, isR.EffectiveDate.UTC(), isR.AwayTeamRuns, isR.HomeTeamRuns, isR.GameID, isR.Inning)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
@@ -17,12 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -58,7 +58,7 @@ func (lR *LeagueRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (lR *LeagueRecord) CreateTable(db *sql.DB) {
+func (lR *LeagueRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS LeagueRecord (
 		effectiveDate 	timestamp with time zone,
 		id 				bigint,
@@ -67,10 +67,7 @@ func (lR *LeagueRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (id, name, sportCode)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -79,56 +76,40 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (lR *LeagueRecord) UpdateRecord(db *sql.DB) {
+func (lR *LeagueRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 		2.  If this is a duplicate record and the effective date is earlier,
 				update the existing record.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO LeagueRecord VALUES ($1,$2,$3,$4);`
-	_, err = db.Exec(statement, lR.EffectiveDate.UTC(), lR.ID, lR.Name, lR.SportCode)
+	statement := `INSERT INTO LeagueRecord VALUES ($1,$2,$3,$4);`
+	_, err = db.ExecContext(ctx, statement, lR.EffectiveDate.UTC(), lR.ID, lR.Name, lR.SportCode)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingEffectiveDate time.Time
-				statement = `SELECT effectiveDate FROM LeagueRecord WHERE
-				id=$1 AND name=$2 AND sportCode=$3;`
-				err = db.QueryRow(statement, lR.ID, lR.Name, lR.SportCode).Scan(&existingEffectiveDate)
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM LeagueRecord WHERE
+			id=$1 AND name=$2 AND sportCode=$3;`
+			err = db.QueryRowContext(ctx, statement, lR.ID, lR.Name, lR.SportCode).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if existingEffectiveDate.Sub(lR.EffectiveDate.UTC()) > 0 {
+				//The new date is before the existing date, so update the record in the database
+				//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, lR.EffectiveDate)
+				statement = `UPDATE LeagueRecord SET effectiveDate=$1 WHERE
+				id=$2 AND name=$3 AND sportCode=$4;`
+				_, err := db.ExecContext(ctx, statement, lR.EffectiveDate.UTC(), lR.ID, lR.Name, lR.SportCode)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if existingEffectiveDate.Sub(lR.EffectiveDate.UTC()) > 0 {
-					//The new date is before the existing date, so update the record in the database
-					//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, lR.EffectiveDate)
-					statement = `UPDATE LeagueRecord SET effectiveDate=$1 WHERE
-					id=$2 AND name=$3 AND sportCode=$4;`
-					_, err := db.Exec(statement, lR.EffectiveDate.UTC(), lR.ID, lR.Name, lR.SportCode)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
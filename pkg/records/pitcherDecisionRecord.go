@@ -0,0 +1,142 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+PitcherDecisionRecord is a decision in a finished game: the winning (W),
+	losing (L) or save (S) pitcher, along with the record of the pitcher
+	once the game was over.  The ERA is kept as the scoreboard writes it,
+	which is "-.--" before the pitcher has recorded an out.
+*/
+type PitcherDecisionRecord struct {
+	RecordName        string
+	EffectiveDate     time.Time
+	GameID            int64
+	Decision          string
+	PlayerID          int64
+	FirstName         string
+	LastName          string
+	Wins              int
+	Losses            int
+	Saves             int
+	SaveOpportunities int
+	ERA               string
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (pdR *PitcherDecisionRecord) ScreenOutput() {
+	fmt.Println(pdR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (pdR *PitcherDecisionRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%s|%d|%s|%s|%d|%d|%d|%d|%s\n",
+		pdR.EffectiveDate.Format(time.UnixDate),
+		pdR.GameID,
+		pdR.Decision,
+		pdR.PlayerID,
+		pdR.FirstName,
+		pdR.LastName,
+		pdR.Wins,
+		pdR.Losses,
+		pdR.Saves,
+		pdR.SaveOpportunities,
+		pdR.ERA,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (pdR *PitcherDecisionRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS PitcherDecisionRecord (
+		effectiveDate 		timestamp with time zone,
+		gameid				bigint,
+		decision			varchar(1),
+		playerid			bigint,
+		firstname			varchar(128),
+		lastname			varchar(128),
+		wins				int,
+		losses				int,
+		saves				int,
+		saveopportunities	int,
+		era					varchar(8),
+		PRIMARY KEY (gameid, decision)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (pdR *PitcherDecisionRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If this is a unique record, insert it.
+		2.  If this is a duplicate record and the effective date is later,
+				update the existing record.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	statement := `INSERT INTO PitcherDecisionRecord VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11);`
+	_, err = db.ExecContext(ctx, statement, pdR.EffectiveDate.UTC(), pdR.GameID, pdR.Decision, pdR.PlayerID,
+		pdR.FirstName, pdR.LastName, pdR.Wins, pdR.Losses, pdR.Saves, pdR.SaveOpportunities, pdR.ERA)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM PitcherDecisionRecord WHERE
+			gameid=$1 AND decision=$2;`
+			err = db.QueryRowContext(ctx, statement, pdR.GameID, pdR.Decision).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if pdR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so update the record in the database
+				statement = `UPDATE PitcherDecisionRecord SET effectiveDate=$1, playerid=$2, firstname=$3,
+				lastname=$4, wins=$5, losses=$6, saves=$7, saveopportunities=$8, era=$9 WHERE
+				gameid=$10 AND decision=$11;`
+				_, err := db.ExecContext(ctx, statement, pdR.EffectiveDate.UTC(), pdR.PlayerID, pdR.FirstName,
+					pdR.LastName, pdR.Wins, pdR.Losses, pdR.Saves, pdR.SaveOpportunities, pdR.ERA,
+					pdR.GameID, pdR.Decision)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+PitchingLineRecord is the pitching line of a single pitcher in a game, as it
+	appears in the boxscore.  Innings pitched are kept as outs recorded, so
+	that they can be added up; 6.2 innings is 20 outs.
+*/
+type PitchingLineRecord struct {
+	RecordName    string
+	EffectiveDate time.Time
+	GameID        int64
+	PlayerID      int64
+	TeamID        int64
+	Name          string
+	Outs          int
+	BattersFaced  int
+	Hits          int
+	Runs          int
+	EarnedRuns    int
+	Walks         int
+	Strikeouts    int
+	HomeRuns      int
+	Pitches       int
+	Strikes       int
+	Win           bool
+	Loss          bool
+	Save          bool
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (plR *PitchingLineRecord) ScreenOutput() {
+	fmt.Println(plR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (plR *PitchingLineRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%d|%d|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%t|%t|%t\n",
+		plR.EffectiveDate.Format(time.UnixDate),
+		plR.GameID,
+		plR.PlayerID,
+		plR.TeamID,
+		plR.Name,
+		plR.Outs,
+		plR.BattersFaced,
+		plR.Hits,
+		plR.Runs,
+		plR.EarnedRuns,
+		plR.Walks,
+		plR.Strikeouts,
+		plR.HomeRuns,
+		plR.Pitches,
+		plR.Strikes,
+		plR.Win,
+		plR.Loss,
+		plR.Save,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (plR *PitchingLineRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS PitchingLineRecord (
+		effectiveDate 	timestamp with time zone,
+		gameid			bigint,
+		playerid		bigint,
+		teamid			bigint,
+		name			varchar(128),
+		outs			int,
+		battersfaced	int,
+		hits			int,
+		runs			int,
+		earnedruns		int,
+		walks			int,
+		strikeouts		int,
+		homeruns		int,
+		pitches			int,
+		strikes			int,
+		win				boolean,
+		loss			boolean,
+		save			boolean,
+		PRIMARY KEY (gameid, playerid)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (plR *PitchingLineRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If this is a unique record, insert it.
+		2.  If this is a duplicate record and the effective date is later,
+				update the existing record.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	statement := `INSERT INTO PitchingLineRecord VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,
+	$14,$15,$16,$17,$18);`
+	_, err = db.ExecContext(ctx, statement, plR.EffectiveDate.UTC(), plR.GameID, plR.PlayerID, plR.TeamID,
+		plR.Name, plR.Outs, plR.BattersFaced, plR.Hits, plR.Runs, plR.EarnedRuns, plR.Walks, plR.Strikeouts,
+		plR.HomeRuns, plR.Pitches, plR.Strikes, plR.Win, plR.Loss, plR.Save)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM PitchingLineRecord WHERE
+			gameid=$1 AND playerid=$2;`
+			err = db.QueryRowContext(ctx, statement, plR.GameID, plR.PlayerID).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if plR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so update the record in the database
+				statement = `UPDATE PitchingLineRecord SET effectiveDate=$1, teamid=$2, name=$3, outs=$4,
+				battersfaced=$5, hits=$6, runs=$7, earnedruns=$8, walks=$9, strikeouts=$10, homeruns=$11,
+				pitches=$12, strikes=$13, win=$14, loss=$15, save=$16 WHERE gameid=$17 AND playerid=$18;`
+				_, err := db.ExecContext(ctx, statement, plR.EffectiveDate.UTC(), plR.TeamID, plR.Name, plR.Outs,
+					plR.BattersFaced, plR.Hits, plR.Runs, plR.EarnedRuns, plR.Walks, plR.Strikeouts, plR.HomeRuns,
+					plR.Pitches, plR.Strikes, plR.Win, plR.Loss, plR.Save, plR.GameID, plR.PlayerID)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+PlayerRecord is the specific data record for each player.  The table keeps
+	every version of a player that has been seen, such as before and after a
+	trade, each effective from the first game it was seen in.  A player who
+	goes back to an earlier version, such as a return to a former team, gets
+	a new version.  The birth date is YYYY-MM-DD, or empty when it isn't
+	known.  It belongs to the player rather than to a version, so it is
+	carried to every version once it has been seen.
+*/
+type PlayerRecord struct {
+	RecordName    string
+	EffectiveDate time.Time
+	ID            int64
+	FirstName     string
+	LastName      string
+	BoxName       string
+	Number        string
+	BirthDate     string
+	Bats          string
+	Throws        string
+	Position      string
+	TeamID        int64
+}
+
+/*
+FullName returns the first and last name of the player
+*/
+func (pR *PlayerRecord) FullName() string {
+	if len(pR.FirstName) == 0 {
+		return pR.LastName
+	}
+	return pR.FirstName + " " + pR.LastName
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (pR *PlayerRecord) ScreenOutput() {
+	fmt.Println(pR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (pR *PlayerRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%s|%s|%s|%s|%s|%s|%s|%s|%d\n",
+		pR.EffectiveDate.Format(time.UnixDate),
+		pR.ID,
+		pR.FirstName,
+		pR.LastName,
+		pR.BoxName,
+		pR.Number,
+		pR.BirthDate,
+		pR.Bats,
+		pR.Throws,
+		pR.Position,
+		pR.TeamID,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (pR *PlayerRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS PlayerRecord (
+		effectiveDate	timestamp with time zone,
+		id 				bigint,
+		firstname		varchar(128),
+		lastname		varchar(128),
+		boxname			varchar(128),
+		number			varchar(8),
+		birthdate		varchar(10),
+		bats			varchar(1),
+		throws			varchar(1),
+		position		varchar(8),
+		teamid			bigint,
+		PRIMARY KEY (id, effectiveDate)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (pR *PlayerRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If the version in effect on the date of this record is the same,
+				there is nothing to do; it keeps the date it was first seen.
+		2.  Otherwise this record is a new version.  It replaces a different
+				version from the same date, and is inserted otherwise.
+		3.  If the next version is the same as this one, it is no longer a
+				change, so remove it.
+		4.  A birth date that has been seen is set on every version that
+				doesn't have one.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	effectiveDate := pR.EffectiveDate.UTC()
+	versions, err := pR.versions(ctx, db)
+	if err != nil {
+		return err
+	}
+	birthDate := pR.BirthDate
+	var current, next *PlayerRecord
+	for i := range versions {
+		if len(birthDate) == 0 {
+			birthDate = versions[i].BirthDate
+		}
+		if versions[i].EffectiveDate.After(effectiveDate) {
+			next = &versions[i]
+			break
+		}
+		current = &versions[i]
+	}
+	if current != nil && current.sameVersion(pR) {
+		return pR.fillBirthDate(ctx, db, versions, birthDate)
+	}
+
+	if current != nil && current.EffectiveDate.Equal(effectiveDate) {
+		statement := `UPDATE PlayerRecord SET firstname=$1, lastname=$2, boxname=$3, number=$4, birthdate=$5,
+		bats=$6, throws=$7, position=$8, teamid=$9 WHERE id=$10 AND effectiveDate=$11;`
+		_, err = db.ExecContext(ctx, statement, pR.FirstName, pR.LastName, pR.BoxName, pR.Number, birthDate,
+			pR.Bats, pR.Throws, pR.Position, pR.TeamID, pR.ID, current.EffectiveDate)
+	} else {
+		statement := `INSERT INTO PlayerRecord VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+		_, err = db.ExecContext(ctx, statement, effectiveDate, pR.ID, pR.FirstName, pR.LastName, pR.BoxName,
+			pR.Number, birthDate, pR.Bats, pR.Throws, pR.Position, pR.TeamID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if next != nil && next.sameVersion(pR) {
+		statement := `DELETE FROM PlayerRecord WHERE id=$1 AND effectiveDate=$2;`
+		_, err = db.ExecContext(ctx, statement, pR.ID, next.EffectiveDate)
+		if err != nil {
+			return err
+		}
+	}
+	return pR.fillBirthDate(ctx, db, versions, birthDate)
+}
+
+/*
+fillBirthDate sets the birth date on the versions of the player that don't
+	have one, such as those seen when the player's file wasn't available
+*/
+func (pR *PlayerRecord) fillBirthDate(ctx context.Context, db *util.Database, versions []PlayerRecord, birthDate string) error {
+	if len(birthDate) == 0 {
+		return nil
+	}
+	for _, version := range versions {
+		if len(version.BirthDate) == 0 {
+			statement := `UPDATE PlayerRecord SET birthdate=$1 WHERE id=$2 AND (birthdate IS NULL OR birthdate='');`
+			_, err := db.ExecContext(ctx, statement, birthDate, pR.ID)
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+versions returns every version of the player in the database, oldest first
+*/
+func (pR *PlayerRecord) versions(ctx context.Context, db *util.Database) ([]PlayerRecord, error) {
+	statement := `SELECT effectiveDate, firstname, lastname, boxname, number, birthdate, bats, throws,
+	position, teamid FROM PlayerRecord WHERE id=$1;`
+	rows, err := db.QueryContext(ctx, statement, pR.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var versions []PlayerRecord
+	for rows.Next() {
+		version := PlayerRecord{RecordName: "PlayerRecord", ID: pR.ID}
+		err = rows.Scan(&version.EffectiveDate, &version.FirstName, &version.LastName, &version.BoxName,
+			&version.Number, &version.BirthDate, &version.Bats, &version.Throws, &version.Position, &version.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		version.EffectiveDate = version.EffectiveDate.UTC()
+		versions = append(versions, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].EffectiveDate.Before(versions[j].EffectiveDate)
+	})
+	return versions, nil
+}
+
+/*
+sameVersion is true when two records describe the player the same way, no
+	matter when they were seen.  The birth date isn't compared because it
+	is missing whenever the player's file couldn't be read
+*/
+func (pR *PlayerRecord) sameVersion(other *PlayerRecord) bool {
+	return pR.ID == other.ID && pR.FirstName == other.FirstName && pR.LastName == other.LastName &&
+		pR.BoxName == other.BoxName && pR.Number == other.Number &&
+		pR.Bats == other.Bats && pR.Throws == other.Throws && pR.Position == other.Position &&
+		pR.TeamID == other.TeamID
+}
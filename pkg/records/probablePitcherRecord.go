@@ -0,0 +1,139 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+ProbablePitcherRecord is the pitcher a team expects to start a game, along
+	with the record of the pitcher going into it.  Throws is the throwing
+	hand as the scoreboard writes it, RHP or LHP.
+*/
+type ProbablePitcherRecord struct {
+	RecordName    string
+	EffectiveDate time.Time
+	GameID        int64
+	TeamID        int64
+	PlayerID      int64
+	FirstName     string
+	LastName      string
+	Number        string
+	Throws        string
+	Wins          int
+	Losses        int
+	ERA           string
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (ppR *ProbablePitcherRecord) ScreenOutput() {
+	fmt.Println(ppR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (ppR *ProbablePitcherRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%d|%d|%s|%s|%s|%s|%d|%d|%s\n",
+		ppR.EffectiveDate.Format(time.UnixDate),
+		ppR.GameID,
+		ppR.TeamID,
+		ppR.PlayerID,
+		ppR.FirstName,
+		ppR.LastName,
+		ppR.Number,
+		ppR.Throws,
+		ppR.Wins,
+		ppR.Losses,
+		ppR.ERA,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (ppR *ProbablePitcherRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS ProbablePitcherRecord (
+		effectiveDate 	timestamp with time zone,
+		gameid			bigint,
+		teamid			bigint,
+		playerid		bigint,
+		firstname		varchar(128),
+		lastname		varchar(128),
+		number			varchar(8),
+		throws			varchar(8),
+		wins			int,
+		losses			int,
+		era				varchar(8),
+		PRIMARY KEY (gameid, teamid)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (ppR *ProbablePitcherRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If this is a unique record, insert it.
+		2.  If this is a duplicate record and the effective date is later,
+				update the existing record.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	statement := `INSERT INTO ProbablePitcherRecord VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11);`
+	_, err = db.ExecContext(ctx, statement, ppR.EffectiveDate.UTC(), ppR.GameID, ppR.TeamID, ppR.PlayerID,
+		ppR.FirstName, ppR.LastName, ppR.Number, ppR.Throws, ppR.Wins, ppR.Losses, ppR.ERA)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM ProbablePitcherRecord WHERE
+			gameid=$1 AND teamid=$2;`
+			err = db.QueryRowContext(ctx, statement, ppR.GameID, ppR.TeamID).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if ppR.EffectiveDate.UTC().Sub(existingEffectiveDate) > 0 {
+				//The new date is after the existing date, so update the record in the database
+				statement = `UPDATE ProbablePitcherRecord SET effectiveDate=$1, playerid=$2, firstname=$3,
+				lastname=$4, number=$5, throws=$6, wins=$7, losses=$8, era=$9 WHERE gameid=$10 AND teamid=$11;`
+				_, err := db.ExecContext(ctx, statement, ppR.EffectiveDate.UTC(), ppR.PlayerID, ppR.FirstName,
+					ppR.LastName, ppR.Number, ppR.Throws, ppR.Wins, ppR.Losses, ppR.ERA, ppR.GameID, ppR.TeamID)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,8 +17,14 @@
 package records
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -27,6 +33,128 @@ Records defines the methods that all record types must implement
 type Records interface {
 	ScreenOutput()
 	FileOutput(*os.File)
-	CreateTable(*sql.DB)
-	UpdateRecord(*sql.DB)
+	CreateTable(context.Context, *util.Database) error
+	UpdateRecord(context.Context, *util.Database) error
+}
+
+/*
+New returns an empty record of the type with the given RecordName, ready to
+	have a JSON-encoded record unmarshalled into it
+*/
+func New(recordName string) (Records, bool) {
+	switch recordName {
+	case "VenueRecord":
+		return &VenueRecord{}, true
+	case "LeagueRecord":
+		return &LeagueRecord{}, true
+	case "DivisionRecord":
+		return &DivisionRecord{}, true
+	case "TeamRecord":
+		return &TeamRecord{}, true
+	case "StandingRecord":
+		return &StandingRecord{}, true
+	case "GameRecord":
+		return &GameRecord{}, true
+	case "GameStatusRecord":
+		return &GameStatusRecord{}, true
+	case "InningScoreRecord":
+		return &InningScoreRecord{}, true
+	case "BattingLineRecord":
+		return &BattingLineRecord{}, true
+	case "PitchingLineRecord":
+		return &PitchingLineRecord{}, true
+	case "PlayerRecord":
+		return &PlayerRecord{}, true
+	case "PitcherDecisionRecord":
+		return &PitcherDecisionRecord{}, true
+	case "ProbablePitcherRecord":
+		return &ProbablePitcherRecord{}, true
+	case "UmpireRecord":
+		return &UmpireRecord{}, true
+	case "GameUmpireAssignmentRecord":
+		return &GameUmpireAssignmentRecord{}, true
+	}
+	return nil, false
+}
+
+/*
+Name returns the RecordName at the start of a JSON-encoded record
+*/
+func Name(record string) (string, bool) {
+	const prefix = "{\"RecordName\":\""
+	if strings.HasPrefix(record, prefix) == false {
+		return "", false
+	}
+	end := strings.Index(record[len(prefix):], "\"")
+	if end < 0 {
+		return "", false
+	}
+	return record[len(prefix) : len(prefix)+end], true
+}
+
+/*
+Key returns the identity of a JSON-encoded record: the fields that make up the
+	primary key of its table.  Two versions of the same record, such as the
+	status of a game before and after a run scores, have the same key.
+*/
+func Key(record string) (string, bool) {
+	var id struct {
+		RecordName    string
+		EffectiveDate time.Time
+		ID            int64
+		GameID        int64
+		PlayerID      int64
+		TeamID        int64
+		LeagueID      int64
+		Inning        int
+		Name          string
+		Code          string
+		City          string
+		Division      string
+		SportCode     string
+		Location      string
+		Channel       string
+		FirstName     string
+		LastName      string
+		BoxName       string
+		Number        string
+		Bats          string
+		Throws        string
+		Position      string
+		Decision      string
+	}
+	if json.Unmarshal([]byte(record), &id) != nil {
+		return "", false
+	}
+
+	switch id.RecordName {
+	case "GameRecord", "GameStatusRecord":
+		return fmt.Sprintf("%s|%d", id.RecordName, id.ID), true
+	case "InningScoreRecord":
+		return fmt.Sprintf("%s|%d|%d", id.RecordName, id.GameID, id.Inning), true
+	case "PlayerRecord":
+		return fmt.Sprintf("%s|%d|%s|%s|%s|%s|%s|%s|%s|%d", id.RecordName, id.ID, id.FirstName, id.LastName,
+			id.BoxName, id.Number, id.Bats, id.Throws, id.Position, id.TeamID), true
+	case "PitcherDecisionRecord":
+		return fmt.Sprintf("%s|%d|%s", id.RecordName, id.GameID, id.Decision), true
+	case "ProbablePitcherRecord":
+		return fmt.Sprintf("%s|%d|%d", id.RecordName, id.GameID, id.TeamID), true
+	case "UmpireRecord":
+		return fmt.Sprintf("%s|%d|%s|%s", id.RecordName, id.ID, id.FirstName, id.LastName), true
+	case "GameUmpireAssignmentRecord":
+		return fmt.Sprintf("%s|%d|%s", id.RecordName, id.GameID, id.Position), true
+	case "BattingLineRecord", "PitchingLineRecord":
+		return fmt.Sprintf("%s|%d|%d", id.RecordName, id.GameID, id.PlayerID), true
+	case "StandingRecord":
+		return fmt.Sprintf("%s|%s|%d", id.RecordName, id.EffectiveDate.UTC().Format(time.RFC3339), id.TeamID), true
+	case "VenueRecord":
+		return fmt.Sprintf("%s|%d|%s|%s|%s", id.RecordName, id.ID, id.Name, id.Location, id.Channel), true
+	case "LeagueRecord":
+		return fmt.Sprintf("%s|%d|%s|%s", id.RecordName, id.ID, id.Name, id.SportCode), true
+	case "DivisionRecord":
+		return fmt.Sprintf("%s|%s|%s", id.RecordName, id.Name, id.Code), true
+	case "TeamRecord":
+		return fmt.Sprintf("%s|%d|%s|%s|%s|%d|%s", id.RecordName, id.ID, id.Name, id.Code, id.City, id.LeagueID, id.Division), true
+	}
+	return "", false
 }
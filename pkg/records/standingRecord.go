@@ -17,12 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -63,7 +63,7 @@ func (sR *StandingRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (sR *StandingRecord) CreateTable(db *sql.DB) {
+func (sR *StandingRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS StandingRecord (
 		effectiveDate 		timestamp with time zone,
 		teamid 				bigint,
@@ -75,10 +75,7 @@ func (sR *StandingRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (effectiveDate, teamid)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -87,52 +84,36 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (sR *StandingRecord) UpdateRecord(db *sql.DB) {
+func (sR *StandingRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO StandingRecord VALUES ($1,$2,$3,$4,$5,$6,$7);`
-	_, err = db.Exec(statement, sR.EffectiveDate.UTC(), sR.TeamID, sR.Wins, sR.Losses, sR.Wins+sR.Losses, sR.GamesBack, sR.WildcardGamesBack)
+	statement := `INSERT INTO StandingRecord VALUES ($1,$2,$3,$4,$5,$6,$7);`
+	_, err = db.ExecContext(ctx, statement, sR.EffectiveDate.UTC(), sR.TeamID, sR.Wins, sR.Losses, sR.Wins+sR.Losses, sR.GamesBack, sR.WildcardGamesBack)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingGamesPlayed int
-				statement = `SELECT gamesplayed FROM StandingRecord WHERE effectivedate = $1 AND teamid = $2;`
-				err = db.QueryRow(statement, sR.EffectiveDate.UTC(), sR.TeamID).Scan(&existingGamesPlayed)
+		if db.IsUniqueViolation(err) {
+			var existingGamesPlayed int
+			statement = `SELECT gamesplayed FROM StandingRecord WHERE effectivedate = $1 AND teamid = $2;`
+			err = db.QueryRowContext(ctx, statement, sR.EffectiveDate.UTC(), sR.TeamID).Scan(&existingGamesPlayed)
+			if err != nil {
+				return err
+			}
+			if sR.Wins+sR.Losses > existingGamesPlayed {
+				statement = `UPDATE StandingRecord SET
+				wins = $1, losses = $2, gamesplayed = $3, gamesback = $4, wildcardgamesback = $5
+				WHERE effectivedate = $6 AND teamid = $7;`
+				_, err = db.ExecContext(ctx, statement, sR.Wins, sR.Losses, sR.GamesBack, sR.WildcardGamesBack, sR.Wins+sR.Losses, sR.EffectiveDate.UTC(), sR.TeamID)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if sR.Wins+sR.Losses > existingGamesPlayed {
-					statement = `UPDATE StandingRecord SET
-					wins = $1, losses = $2, gamesplayed = $3, gamesback = $4, wildcardgamesback = $5
-					WHERE effectivedate = $6 AND teamid = $7;`
-					_, err = db.Exec(statement, sR.Wins, sR.Losses, sR.GamesBack, sR.WildcardGamesBack, sR.Wins+sR.Losses, sR.EffectiveDate.UTC(), sR.TeamID)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
@@ -17,12 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -64,7 +64,7 @@ func (tR *TeamRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (tR *TeamRecord) CreateTable(db *sql.DB) {
+func (tR *TeamRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS TeamRecord (
 		effectiveDate	timestamp with time zone,
 		id 				bigint,
@@ -76,10 +76,7 @@ func (tR *TeamRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (id, name, code, city, leagueid, division)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -88,56 +85,40 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (tR *TeamRecord) UpdateRecord(db *sql.DB) {
+func (tR *TeamRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 		2.  If this is a duplicate record and the effective date is earlier,
 				update the existing record.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO TeamRecord VALUES ($1, $2, $3, $4, $5, $6, $7);`
-	_, err = db.Exec(statement, tR.EffectiveDate.UTC(), tR.ID, tR.Name, tR.Code, tR.City, tR.LeagueID, tR.Division)
+	statement := `INSERT INTO TeamRecord VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	_, err = db.ExecContext(ctx, statement, tR.EffectiveDate.UTC(), tR.ID, tR.Name, tR.Code, tR.City, tR.LeagueID, tR.Division)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingEffectiveDate time.Time
-				statement = `SELECT effectiveDate FROM TeamRecord WHERE
-				id=$1 AND name=$2 AND code=$3 AND city=$4 AND leagueid=$5 AND division=$6;`
-				err = db.QueryRow(statement, tR.ID, tR.Name, tR.Code, tR.City, tR.LeagueID, tR.Division).Scan(&existingEffectiveDate)
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM TeamRecord WHERE
+			id=$1 AND name=$2 AND code=$3 AND city=$4 AND leagueid=$5 AND division=$6;`
+			err = db.QueryRowContext(ctx, statement, tR.ID, tR.Name, tR.Code, tR.City, tR.LeagueID, tR.Division).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if existingEffectiveDate.Sub(tR.EffectiveDate.UTC()) > 0 {
+				//The new date is before the existing date, so update the record in the database
+				//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, tR.EffectiveDate)
+				statement = `UPDATE TeamRecord SET effectiveDate=$1 WHERE
+				id=$2 AND name=$3 AND code=$4 AND city=$5 AND leagueid=$6 AND division=$7;`
+				_, err := db.ExecContext(ctx, statement, tR.EffectiveDate.UTC(), tR.ID, tR.Name, tR.Code, tR.City, tR.LeagueID, tR.Division)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if existingEffectiveDate.Sub(tR.EffectiveDate.UTC()) > 0 {
-					//The new date is before the existing date, so update the record in the database
-					//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, tR.EffectiveDate)
-					statement = `UPDATE TeamRecord SET effectiveDate=$1 WHERE
-					id=$2 AND name=$3 AND code=$4 AND city=$5 AND leagueid=$6 AND division=$7;`
-					_, err := db.Exec(statement, tR.EffectiveDate.UTC(), tR.ID, tR.Name, tR.Code, tR.City, tR.LeagueID, tR.Division)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
@@ -0,0 +1,116 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package records
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bauer312/baseball/pkg/util"
+)
+
+/*
+UmpireRecord is the specific data record for each umpire.  Like the team
+	table, every version of an umpire that has been seen is kept, effective
+	from the first game it was seen in.
+*/
+type UmpireRecord struct {
+	RecordName    string
+	EffectiveDate time.Time
+	ID            int64
+	FirstName     string
+	LastName      string
+}
+
+/*
+ScreenOutput displays the record on the screen
+*/
+func (uR *UmpireRecord) ScreenOutput() {
+	fmt.Println(uR)
+}
+
+/*
+FileOutput displays the record on the screen
+*/
+func (uR *UmpireRecord) FileOutput(filePtr *os.File) {
+	fmt.Fprintf(filePtr, "%s|%d|%s|%s\n",
+		uR.EffectiveDate.Format(time.UnixDate),
+		uR.ID,
+		uR.FirstName,
+		uR.LastName,
+	)
+}
+
+/*
+CreateTable will create the requisite database table
+*/
+func (uR *UmpireRecord) CreateTable(ctx context.Context, db *util.Database) error {
+	statement := `CREATE TABLE IF NOT EXISTS UmpireRecord (
+		effectiveDate	timestamp with time zone,
+		id 				bigint,
+		firstname		varchar(128),
+		lastname		varchar(128),
+		PRIMARY KEY (id, firstname, lastname)
+	)`
+
+	return db.CreateTable(ctx, statement)
+}
+
+/*
+UpdateRecord is the way data gets into the database.  It does not act like
+	the UPSERT command because the effective date field will be different
+	for each record.  Each table in the database will have different rules
+	for how to deal with data records
+*/
+func (uR *UmpireRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
+	/*
+		1.  If this is a unique record, insert it.
+		2.  If this is a duplicate record and the effective date is earlier,
+				update the existing record.
+	*/
+	err := db.UseUTC(ctx)
+	if err != nil {
+		return err
+	}
+	statement := `INSERT INTO UmpireRecord VALUES ($1, $2, $3, $4);`
+	_, err = db.ExecContext(ctx, statement, uR.EffectiveDate.UTC(), uR.ID, uR.FirstName, uR.LastName)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM UmpireRecord WHERE
+			id=$1 AND firstname=$2 AND lastname=$3;`
+			err = db.QueryRowContext(ctx, statement, uR.ID, uR.FirstName, uR.LastName).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if existingEffectiveDate.Sub(uR.EffectiveDate.UTC()) > 0 {
+				//The new date is before the existing date, so update the record in the database
+				statement = `UPDATE UmpireRecord SET effectiveDate=$1 WHERE
+				id=$2 AND firstname=$3 AND lastname=$4;`
+				_, err := db.ExecContext(ctx, statement, uR.EffectiveDate.UTC(), uR.ID, uR.FirstName, uR.LastName)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,13 +17,12 @@
 package records
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"time"
 
-	//Make sure we can use Postgres
-	pq "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -61,7 +60,7 @@ func (vR *VenueRecord) FileOutput(filePtr *os.File) {
 /*
 CreateTable will create the requisite database table
 */
-func (vR *VenueRecord) CreateTable(db *sql.DB) {
+func (vR *VenueRecord) CreateTable(ctx context.Context, db *util.Database) error {
 	statement := `CREATE TABLE IF NOT EXISTS VenueRecord (
 		effectiveDate 	timestamp with time zone,
 		id 				bigint,
@@ -71,10 +70,7 @@ func (vR *VenueRecord) CreateTable(db *sql.DB) {
 		PRIMARY KEY (id, name, location, channel)
 	)`
 
-	_, err := db.Exec(statement)
-	if err != nil {
-		fmt.Println(err)
-	}
+	return db.CreateTable(ctx, statement)
 }
 
 /*
@@ -83,56 +79,40 @@ UpdateRecord is the way data gets into the database.  It does not act like
 	for each record.  Each table in the database will have different rules
 	for how to deal with data records
 */
-func (vR *VenueRecord) UpdateRecord(db *sql.DB) {
+func (vR *VenueRecord) UpdateRecord(ctx context.Context, db *util.Database) error {
 	/*
 		1.  If this is a unique record, insert it.
 		2.  If this is a duplicate record and the effective date is earlier,
 				update the existing record.
 	*/
-	statement := `SET timezone='UTC';`
-	_, err := db.Exec(statement)
+	err := db.UseUTC(ctx)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqerr.Code.Name())
-		} else {
-			fmt.Println(err)
-		}
+		return err
 	}
-	statement = `INSERT INTO VenueRecord VALUES ($1,$2,$3,$4,$5);`
-	_, err = db.Exec(statement, vR.EffectiveDate.UTC(), vR.ID, vR.Name, vR.Location, vR.Channel)
+	statement := `INSERT INTO VenueRecord VALUES ($1,$2,$3,$4,$5);`
+	_, err = db.ExecContext(ctx, statement, vR.EffectiveDate.UTC(), vR.ID, vR.Name, vR.Location, vR.Channel)
 	if err != nil {
-		if pqerr, ok := err.(*pq.Error); ok {
-			if pqerr.Code.Name() == "unique_violation" {
-				var existingEffectiveDate time.Time
-				statement = `SELECT effectiveDate FROM VenueRecord WHERE
-				id=$1 AND name=$2 AND location=$3 AND channel=$4;`
-				err = db.QueryRow(statement, vR.ID, vR.Name, vR.Location, vR.Channel).Scan(&existingEffectiveDate)
+		if db.IsUniqueViolation(err) {
+			var existingEffectiveDate time.Time
+			statement = `SELECT effectiveDate FROM VenueRecord WHERE
+			id=$1 AND name=$2 AND location=$3 AND channel=$4;`
+			err = db.QueryRowContext(ctx, statement, vR.ID, vR.Name, vR.Location, vR.Channel).Scan(&existingEffectiveDate)
+			if err != nil {
+				return err
+			}
+			if existingEffectiveDate.Sub(vR.EffectiveDate.UTC()) > 0 {
+				//The new date is before the existing date, so update the record in the database
+				//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, vR.EffectiveDate)
+				statement = `UPDATE VenueRecord SET effectiveDate=$1 WHERE
+				id=$2 AND name=$3 AND location=$4 AND channel=$5;`
+				_, err := db.ExecContext(ctx, statement, vR.EffectiveDate.UTC(), vR.ID, vR.Name, vR.Location, vR.Channel)
 				if err != nil {
-					if pqerr, ok := err.(*pq.Error); ok {
-						fmt.Println("pq error:", pqerr.Code.Name())
-					} else {
-						fmt.Println(err)
-					}
-				}
-				if existingEffectiveDate.Sub(vR.EffectiveDate.UTC()) > 0 {
-					//The new date is before the existing date, so update the record in the database
-					//fmt.Printf("Existing: %v New: %v --> Updating record in DB\n", existingEffectiveDate, vR.EffectiveDate)
-					statement = `UPDATE VenueRecord SET effectiveDate=$1 WHERE
-					id=$2 AND name=$3 AND location=$4 AND channel=$5;`
-					_, err := db.Exec(statement, vR.EffectiveDate.UTC(), vR.ID, vR.Name, vR.Location, vR.Channel)
-					if err != nil {
-						if pqerr, ok := err.(*pq.Error); ok {
-							fmt.Println("pq error:", pqerr.Code.Name())
-						} else {
-							fmt.Println(err)
-						}
-					}
+					return err
 				}
-			} else {
-				fmt.Println("pq error:", pqerr.Code.Name())
 			}
 		} else {
-			fmt.Println(err)
+			return err
 		}
 	}
+	return nil
 }
@@ -17,14 +17,12 @@
 package reports
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"path"
 
-	//Make sure we can use Postgres
-	_ "github.com/lib/pq"
+	"github.com/bauer312/baseball/pkg/util"
 )
 
 /*
@@ -40,9 +38,9 @@ type StandingsReport struct {
 
 /*
 GetStandingsReport retrieves data as of the provided date, using the provided
-	database connection.
+	database connection.  The query works the same on Postgres and SQLite.
 */
-func GetStandingsReport(db *sql.DB, asOf, league, division, output string) {
+func GetStandingsReport(ctx context.Context, db *util.Database, asOf, league, division, output string) error {
 	statement := `SELECT tr.name "Name", sr.wins "Wins", sr.losses "Losses"
 	FROM StandingRecord sr
 	JOIN TeamRecord tr ON
@@ -56,9 +54,9 @@ func GetStandingsReport(db *sql.DB, asOf, league, division, output string) {
 	AND lr.name = $2 AND dr.name = $3
 	ORDER BY sr.wins desc;`
 
-	rows, err := db.Query(statement, asOf, league, division)
+	rows, err := db.QueryContext(ctx, statement, asOf, league, division)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	defer rows.Close()
 
@@ -72,7 +70,7 @@ func GetStandingsReport(db *sql.DB, asOf, league, division, output string) {
 	for rows.Next() {
 		var newRecord StandingsReport
 		if err := rows.Scan(&newRecord.Name, &newRecord.Wins, &newRecord.Losses); err != nil {
-			log.Fatal(err)
+			return err
 		}
 		totalGames := newRecord.Wins + newRecord.Losses
 		newRecord.WinningPct = float32(newRecord.Wins) / float32(totalGames)
@@ -99,13 +97,10 @@ func GetStandingsReport(db *sql.DB, asOf, league, division, output string) {
 		filePath := path.Join(output, fileName)
 		ptr, err := os.Create(filePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		defer ptr.Close()
 		fmt.Fprintf(ptr, "%s", outputString)
 	}
-	if err := rows.Err(); err != nil {
-		log.Fatal(err)
-	}
+	return rows.Err()
 }
@@ -0,0 +1,232 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package summary
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+/*
+The names of the counters kept by most commands
+*/
+const (
+	DatesRequested  = "dates requested"
+	GamesFound      = "games found"
+	FilesDownloaded = "files downloaded"
+	BytesDownloaded = "bytes downloaded"
+)
+
+/*
+RowsLoaded is the name of the counter for the rows loaded into a table
+*/
+func RowsLoaded(table string) string {
+	return "rows loaded into " + table
+}
+
+/*
+Unknown is the name of the counter for a value that was read but isn't known,
+	such as a league that isn't in the catalog, so that the summary says
+	which values turned up and how often
+*/
+func Unknown(kind, value string) string {
+	return fmt.Sprintf("unknown %s %q", kind, value)
+}
+
+/*
+Summary keeps track of the work a command was asked to do and how much of it
+	finished, so that a run that fails or is interrupted can say what is left.
+	Every method can be called on a nil Summary, which does nothing, so code
+	deep inside a command doesn't have to check whether anybody is listening.
+*/
+type Summary struct {
+	mu       sync.Mutex
+	name     string
+	pending  map[string]bool
+	done     int
+	failures map[string]string
+	counters map[string]int64
+}
+
+/*
+maxFailuresShown limits how many failures Print lists one by one
+*/
+const maxFailuresShown = 20
+
+/*
+New creates an empty summary for a command
+*/
+func New(name string) *Summary {
+	return &Summary{
+		name:     name,
+		pending:  make(map[string]bool),
+		failures: make(map[string]string),
+		counters: make(map[string]int64),
+	}
+}
+
+type contextKey struct{}
+
+/*
+NewContext returns a copy of the context that carries the summary
+*/
+func NewContext(ctx context.Context, s *Summary) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+/*
+FromContext returns the summary carried by the context, or nil if there is none
+*/
+func FromContext(ctx context.Context) *Summary {
+	s, _ := ctx.Value(contextKey{}).(*Summary)
+	return s
+}
+
+/*
+Request notes that an item of work has been started
+*/
+func (s *Summary) Request(item string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[item] = true
+}
+
+/*
+Done notes that an item of work finished successfully.  An item that failed
+	before and was then tried again is no longer counted as a failure.
+*/
+func (s *Summary) Done(item string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, item)
+	delete(s.failures, item)
+	s.done++
+}
+
+/*
+Finished returns the number of items of work that finished successfully
+*/
+func (s *Summary) Finished() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+/*
+Fail notes that an item of work did not finish and why
+*/
+func (s *Summary) Fail(item string, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		err = fmt.Errorf("failed")
+	}
+	delete(s.pending, item)
+	s.failures[item] = err.Error()
+}
+
+/*
+Add increases one of the counters reported with the summary
+*/
+func (s *Summary) Add(counter string, n int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[counter] += n
+}
+
+/*
+Count returns the current value of a counter
+*/
+func (s *Summary) Count(counter string) int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counters[counter]
+}
+
+/*
+Failed is the number of items that did not finish
+*/
+func (s *Summary) Failed() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.failures)
+}
+
+/*
+Print writes the summary.  Work that was started but neither finished nor
+	failed was cut short, which only happens when the run was interrupted.
+*/
+func (s *Summary) Print(w io.Writer, interrupted bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := "finished"
+	if interrupted {
+		status = "interrupted"
+	}
+	fmt.Fprintf(w, "%s %s: %d done, %d failed, %d not finished\n",
+		s.name, status, s.done, len(s.failures), len(s.pending))
+
+	counters := make([]string, 0, len(s.counters))
+	for counter := range s.counters {
+		counters = append(counters, counter)
+	}
+	sort.Strings(counters)
+	for _, counter := range counters {
+		fmt.Fprintf(w, "\t%s: %d\n", counter, s.counters[counter])
+	}
+
+	items := make([]string, 0, len(s.failures))
+	for item := range s.failures {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	for i, item := range items {
+		if i == maxFailuresShown {
+			fmt.Fprintf(w, "\t... and %d more failures\n", len(items)-i)
+			break
+		}
+		fmt.Fprintf(w, "\tfailed %s: %s\n", item, s.failures[item])
+	}
+}
@@ -0,0 +1,61 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package summary
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	s := New("gameday")
+	s.Request("20190610")
+	s.Done("20190610")
+	s.Request("20190611")
+	s.Fail("20190611", errors.New("404 Not Found"))
+	s.Request("20190612")
+	s.Add(GamesFound, 15)
+	s.Add(BytesDownloaded, 1024)
+	s.Add(BytesDownloaded, 1024)
+	s.Add(RowsLoaded("mlb_savant"), 300)
+
+	var out bytes.Buffer
+	s.Print(&out, true)
+	expected := "gameday interrupted: 1 done, 1 failed, 1 not finished\n" +
+		"\tbytes downloaded: 2048\n" +
+		"\tgames found: 15\n" +
+		"\trows loaded into mlb_savant: 300\n" +
+		"\tfailed 20190611: 404 Not Found\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+	if s.Failed() != 1 {
+		t.Errorf("Expected 1 failure, got %d", s.Failed())
+	}
+	if s.Count(BytesDownloaded) != 2048 {
+		t.Errorf("Expected 2048 bytes, got %d", s.Count(BytesDownloaded))
+	}
+
+	// A nil summary is allowed everywhere
+	var empty *Summary
+	empty.Add(GamesFound, 1)
+	empty.Fail("20190611", errors.New("ignored"))
+	if empty.Failed() != 0 {
+		t.Errorf("Expected no failures from a nil summary")
+	}
+}
@@ -0,0 +1,240 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+/*
+Formats that downloaded files can be stored in.  The name of a compressed
+	format is also the extension added to the files stored in it.
+*/
+const (
+	CompressNone = "none"
+	CompressGzip = "gz"
+	CompressZstd = "zst"
+)
+
+/*
+CompressHelp describes the values accepted by ParseCompression so that every
+	command can use the same flag description
+*/
+const CompressHelp = "Store downloaded files as `format`: none (plain), gz or zst"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+/*
+ParseCompression turns the name of a format into one of CompressNone,
+	CompressGzip or CompressZstd.  An empty name is CompressNone, and gzip
+	and zstd are accepted as well.
+*/
+func ParseCompression(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", CompressNone:
+		return CompressNone, nil
+	case CompressGzip, "gzip":
+		return CompressGzip, nil
+	case CompressZstd, "zstd":
+		return CompressZstd, nil
+	}
+	return "", fmt.Errorf("unknown compression %s (expected none, gz or zst)", name)
+}
+
+/*
+CompressionOf returns the format of a file from its extension
+*/
+func CompressionOf(path string) string {
+	switch {
+	case strings.HasSuffix(path, "."+CompressGzip):
+		return CompressGzip
+	case strings.HasSuffix(path, "."+CompressZstd):
+		return CompressZstd
+	}
+	return CompressNone
+}
+
+/*
+CompressedPath is where a file is stored in a format: the path with the
+	extension of the format added
+*/
+func CompressedPath(path, format string) string {
+	if format == CompressGzip || format == CompressZstd {
+		return path + "." + format
+	}
+	return path
+}
+
+/*
+TrimCompression removes the extension of a compressed format, so that
+	20190610.csv.zst can be recognized as a CSV file
+*/
+func TrimCompression(path string) string {
+	if format := CompressionOf(path); format != CompressNone {
+		return strings.TrimSuffix(path, "."+format)
+	}
+	return path
+}
+
+/*
+FindFile returns the path that a file is stored at, whatever format it is
+	stored in.  The path is given without the extension of a compressed
+	format.
+*/
+func FindFile(path string) (string, bool) {
+	for _, format := range []string{CompressNone, CompressZstd, CompressGzip} {
+		stored := CompressedPath(path, format)
+		if info, err := os.Stat(stored); err == nil && info.Mode().IsRegular() {
+			return stored, true
+		}
+	}
+	return "", false
+}
+
+/*
+OpenFile opens a file that may be compressed.  The format is found from the
+	first bytes of the file rather than from its name, so a plain file, a
+	gzip file and a zstd file can all be read the same way.
+*/
+func OpenFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &compressedFile{Reader: gz, close: gz.Close, file: f}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &compressedFile{Reader: zr, close: func() error { zr.Close(); return nil }, file: f}, nil
+	}
+	return &compressedFile{Reader: r, file: f}, nil
+}
+
+/*
+ReadFile reads the whole of a file that may be compressed (see OpenFile)
+*/
+func ReadFile(path string) ([]byte, error) {
+	r, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+/*
+compressedFile closes the decompressor along with the file underneath it
+*/
+type compressedFile struct {
+	io.Reader
+	close func() error
+	file  *os.File
+}
+
+func (cf *compressedFile) Close() error {
+	var err error
+	if cf.close != nil {
+		err = cf.close()
+	}
+	if fileErr := cf.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
+
+/*
+NewCompressor returns a writer that compresses what is written to it in a
+	format.  Closing it finishes the compressed stream but leaves w open.
+*/
+func NewCompressor(w io.Writer, format string) (io.WriteCloser, error) {
+	switch format {
+	case CompressNone:
+		return nopCloser{w}, nil
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	}
+	return nil, fmt.Errorf("unknown compression %s", format)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+/*
+WriteFileCompressed writes a file atomically (see WriteFileAtomic), in the
+	format given by the extension of the path.  Once the file is in place,
+	any copy of it stored in another format is removed so that the file is
+	never read twice.  The number of bytes before compression is returned.
+*/
+func WriteFileCompressed(path string, write func(io.Writer) error) (int64, error) {
+	format := CompressionOf(path)
+	counter := &countingWriter{}
+	_, err := WriteFileAtomic(path, func(w io.Writer) error {
+		c, err := NewCompressor(w, format)
+		if err != nil {
+			return err
+		}
+		counter.w = c
+		if err = write(counter); err != nil {
+			return err
+		}
+		return c.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	plain := TrimCompression(path)
+	for _, other := range []string{CompressNone, CompressGzip, CompressZstd} {
+		if other != format {
+			if err := os.Remove(CompressedPath(plain, other)); err != nil && os.IsNotExist(err) == false {
+				return counter.n, err
+			}
+		}
+	}
+	return counter.n, nil
+}
@@ -0,0 +1,75 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileCompressed(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Repeat("<atbat num=\"1\"/>\n", 100)
+	plain := filepath.Join(dir, "gid_2019_06_10_nyamlb_bosmlb_1_inning_inning_all.xml")
+
+	var compressTest = []struct {
+		Format       string
+		ExpectedPath string
+	}{
+		{CompressNone, plain},
+		{CompressGzip, plain + ".gz"},
+		{CompressZstd, plain + ".zst"},
+		{CompressNone, plain},
+	}
+
+	for _, ex := range compressTest {
+		path := CompressedPath(plain, ex.Format)
+		size, err := WriteFileCompressed(path, func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(len(content)) {
+			t.Errorf("%s: expected %d bytes written, got %d", ex.Format, len(content), size)
+		}
+
+		stored, ok := FindFile(plain)
+		if ok == false || stored != ex.ExpectedPath {
+			t.Errorf("%s: expected the file at %s, found %s", ex.Format, ex.ExpectedPath, stored)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("%s: expected only one copy of the file, found %d files", ex.Format, len(entries))
+		}
+		data, err := ReadFile(stored)
+		if err != nil || string(data) != content {
+			t.Errorf("%s: the file does not read back (%v)", ex.Format, err)
+		}
+		if TrimCompression(stored) != plain {
+			t.Errorf("%s: expected %s without its extension, got %s", ex.Format, plain, TrimCompression(stored))
+		}
+	}
+
+	if _, err := ParseCompression("lz4"); err == nil {
+		t.Errorf("Expected an error for an unknown compression")
+	}
+}
@@ -17,43 +17,146 @@
 package util
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"strings"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 /*
-GetDBConnection provides a common database connection source for all of the tools
+The database drivers that can be used to store baseball data
 */
-func GetDBConnection() (*sql.DB, error) {
-	dbuser, ok := os.LookupEnv("BASEBALL_DB_USER")
-	if ok == false {
-		return nil, errors.New("BASEBALL_DB_USER environment variable is not set")
-	}
-	dbpass, ok := os.LookupEnv("BASEBALL_DB_PASS")
-	if ok == false {
-		return nil, errors.New("BASEBALL_DB_PASS environment variable is not set")
-	}
-	dbname, ok := os.LookupEnv("BASEBALL_DB_NAME")
-	if ok == false {
-		return nil, errors.New("BASEBALL_DB_NAME environment variable is not set")
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+/*
+sqliteOptions makes SQLite wait for a writer instead of failing right away,
+	and store times in a format that sorts and scans back into a time.Time
+*/
+const sqliteOptions = "_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+/*
+Database is a connection to either Postgres or SQLite.  It hides the few
+	places where the SQL that the tools need differs between the two.
+*/
+type Database struct {
+	*sql.DB
+	Driver string
+}
+
+/*
+GetDBConnection provides a common database connection source for all of the tools.
+	The connection string comes from the database.dsn setting (see the config
+	package).  A string that starts with sqlite: names a SQLite database file,
+	such as sqlite:/data/baseball.db; anything else is handed to Postgres and
+	may be a postgres:// URL or a list of key=value pairs.
+*/
+func GetDBConnection(dsn string) (*Database, error) {
+	if len(dsn) == 0 {
+		return nil, errors.New("no database has been configured")
 	}
-	dbhost, ok := os.LookupEnv("BASEBALL_DB_HOST")
-	if ok == false {
-		dbhost = "localhost"
+
+	d := &Database{Driver: Postgres}
+	source := dsn
+	if strings.HasPrefix(dsn, "sqlite:") {
+		d.Driver = SQLite
+		path := strings.TrimPrefix(dsn, "sqlite:")
+		if len(path) == 0 {
+			return nil, errors.New("the sqlite database needs a file name, such as sqlite:/data/baseball.db")
+		}
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		source = path + separator + sqliteOptions
 	}
 
-	connectionString := fmt.Sprintf("user='%s' password='%s' dbname='%s' host='%s' sslmode='%s'",
-		dbuser, dbpass, dbname, dbhost, "disable")
-	db, err := sql.Open("postgres", connectionString)
+	db, err := sql.Open(d.Driver, source)
 	if err != nil {
 		return nil, err
 	}
+	if d.Driver == SQLite {
+		// SQLite allows a single writer, so every statement shares one connection
+		db.SetMaxOpenConns(1)
+	}
 	err = db.Ping()
 	if err != nil {
-		fmt.Println("Unable to ping the database")
+		slog.Error("Unable to ping the database", "driver", d.Driver, "err", err)
+		db.Close()
 		return nil, err
 	}
-	return db, nil
+	d.DB = db
+	return d, nil
+}
+
+/*
+CreateTable runs a CREATE TABLE statement written for Postgres.  SQLite only
+	recognizes a column as a time when it is declared as a plain timestamp.
+*/
+func (d *Database) CreateTable(ctx context.Context, statement string) error {
+	if d.Driver == SQLite {
+		statement = strings.ReplaceAll(statement, "timestamp with time zone", "timestamp")
+	}
+	_, err := d.ExecContext(ctx, statement)
+	return err
+}
+
+/*
+UseUTC makes the session report times in UTC.  SQLite has no session time
+	zone; times are stored exactly as they are given.
+*/
+func (d *Database) UseUTC(ctx context.Context) error {
+	if d.Driver != Postgres {
+		return nil
+	}
+	_, err := d.ExecContext(ctx, `SET timezone='UTC';`)
+	return err
+}
+
+/*
+IsUniqueViolation is true when an insert failed because the row is already there
+*/
+func (d *Database) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "unique_violation"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	return false
+}
+
+/*
+CopyIn returns the statement used to bulk load rows into a table.  Postgres
+	uses COPY, which has to be finished with FinishCopy; SQLite inserts the
+	rows one at a time inside the transaction, which is just as quick.
+*/
+func (d *Database) CopyIn(table string, columns ...string) string {
+	if d.Driver == Postgres {
+		return pq.CopyIn(table, columns...)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders)
+}
+
+/*
+FinishCopy flushes the rows buffered by a statement made from CopyIn
+*/
+func (d *Database) FinishCopy(ctx context.Context, stmt *sql.Stmt) error {
+	if d.Driver != Postgres {
+		return nil
+	}
+	_, err := stmt.ExecContext(ctx)
+	return err
 }
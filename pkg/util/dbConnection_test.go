@@ -0,0 +1,72 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteDatabase(t *testing.T) {
+	ctx := context.Background()
+	db, err := GetDBConnection("sqlite:" + filepath.Join(t.TempDir(), "baseball.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.CreateTable(ctx, `CREATE TABLE IF NOT EXISTS TestRecord (
+		effectiveDate	timestamp with time zone,
+		id				bigint,
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.UseUTC(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := db.PrepareContext(ctx, db.CopyIn("TestRecord", "effectiveDate", "id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	effectiveDate := time.Date(2019, time.June, 10, 23, 10, 0, 0, time.UTC)
+	if _, err = stmt.ExecContext(ctx, effectiveDate, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.FinishCopy(ctx, stmt); err != nil {
+		t.Fatal(err)
+	}
+	stmt.Close()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO TestRecord VALUES ($1, $2);`, effectiveDate, 1)
+	if db.IsUniqueViolation(err) == false {
+		t.Errorf("Expected a unique violation, got %v", err)
+	}
+
+	var existing time.Time
+	err = db.QueryRowContext(ctx, `SELECT effectiveDate FROM TestRecord WHERE id=$1;`, 1).Scan(&existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing.Equal(effectiveDate) == false {
+		t.Errorf("Expected %v, got %v", effectiveDate, existing)
+	}
+}
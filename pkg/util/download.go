@@ -0,0 +1,126 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+/*
+StatusError is returned for a response that was not a 2xx
+*/
+type StatusError struct {
+	URL        string
+	Status     string
+	StatusCode int
+}
+
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %s", se.URL, se.Status)
+}
+
+/*
+CheckResponse returns a StatusError unless the server answered with a 2xx
+*/
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	se := &StatusError{Status: resp.Status, StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		se.URL = resp.Request.URL.String()
+	}
+	return se
+}
+
+/*
+SaveResponse writes the body of a 2xx response to a file with
+	WriteFileCompressed, so a path ending in .gz or .zst is compressed, and
+	closes the body.  Any other response leaves the file as it was.  The
+	number of bytes in the body is returned.
+*/
+func SaveResponse(resp *http.Response, path string) (int64, error) {
+	defer resp.Body.Close()
+	if err := CheckResponse(resp); err != nil {
+		return 0, err
+	}
+	return WriteFileCompressed(path, func(w io.Writer) error {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	})
+}
+
+/*
+WriteFileAtomic writes a file so that it is either complete or not there at
+	all.  The content goes to a hidden temporary file in the same directory,
+	which is synced to disk and then renamed over the path.  If write returns
+	an error, or the process is killed part way through, the path is
+	untouched.  The number of bytes written is returned.
+*/
+func WriteFileAtomic(path string, write func(io.Writer) error) (int64, error) {
+	dir, name := filepath.Split(path)
+	if len(dir) == 0 {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	// Remove does nothing once the file has been renamed into place
+	defer os.Remove(tmp.Name())
+
+	counter := &countingWriter{w: tmp}
+	err = write(counter)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+
+	// The rename itself only survives a crash once the directory is synced;
+	//	not every platform allows that, so it is only attempted
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return counter.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
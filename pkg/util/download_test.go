@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("<game/>"))
+		case "/cut":
+			// Promise more than is sent, so the body ends early
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("<game>"))
+		default:
+			http.Error(w, "<html>Not Found</html>", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	var saveTest = []struct {
+		Path            string
+		ExpectedContent string
+		ExpectedStatus  int
+		ExpectedError   bool
+	}{
+		{"/ok", "<game/>", 0, false},
+		{"/missing", "old", http.StatusNotFound, true},
+		{"/cut", "old", 0, true},
+	}
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	for _, ex := range saveTest {
+		dir := t.TempDir()
+		target := filepath.Join(dir, "inning_all.xml")
+		if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := GetContext(context.Background(), client, ts.URL+ex.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = SaveResponse(resp, target)
+		if (err != nil) != ex.ExpectedError {
+			t.Errorf("%s: unexpected error %v", ex.Path, err)
+		}
+		var se *StatusError
+		if ex.ExpectedStatus != 0 && (errors.As(err, &se) == false || se.StatusCode != ex.ExpectedStatus) {
+			t.Errorf("%s: expected a %d status error, got %v", ex.Path, ex.ExpectedStatus, err)
+		}
+
+		content, _ := os.ReadFile(target)
+		if string(content) != ex.ExpectedContent {
+			t.Errorf("%s: expected %q on disk, got %q", ex.Path, ex.ExpectedContent, content)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("%s: expected only the target in the directory, found %d files", ex.Path, len(entries))
+		}
+	}
+}
@@ -0,0 +1,267 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+Getter is anything that can make an HTTP request.  Both *http.Client and
+	*Fetcher satisfy it, so the downloaders and pipeline stages accept either one.
+*/
+type Getter interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+/*
+GetContext retrieves a URL with a request that is cancelled along with the context
+*/
+func GetContext(ctx context.Context, client Getter, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+/*
+FetcherConfig controls how politely a Fetcher talks to a server
+*/
+type FetcherConfig struct {
+	// Rate is the number of requests per second that may be started; zero means no limit
+	Rate float64
+	// Burst is the number of requests that may be started at once after a quiet period
+	Burst int
+	// Workers is the number of requests that may be waiting on the server at the same time
+	Workers int
+	// Retries is the number of times a request is repeated after a timeout or a 5xx
+	Retries int
+	// Backoff is the wait before the first retry; it doubles with each attempt
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+}
+
+/*
+Fetcher is the shared HTTP fetcher used by every command and pipeline stage.
+	It rate limits requests with a token bucket, bounds the number of requests
+	in flight, and retries timeouts and server errors with exponential backoff
+	and jitter, honoring the Retry-After header when the server sends one.
+*/
+type Fetcher struct {
+	client  *http.Client
+	config  FetcherConfig
+	limiter *tokenBucket
+	workers chan struct{}
+}
+
+/*
+NewFetcher wraps an HTTP client (see NewSourceClient) in a Fetcher
+*/
+func NewFetcher(client *http.Client, config FetcherConfig) *Fetcher {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = time.Second
+	}
+	if config.MaxBackoff < config.Backoff {
+		config.MaxBackoff = 60 * time.Second
+	}
+	f := &Fetcher{
+		client:  client,
+		config:  config,
+		workers: make(chan struct{}, config.Workers),
+	}
+	if config.Rate > 0 {
+		f.limiter = newTokenBucket(config.Rate, config.Burst)
+	}
+	return f
+}
+
+/*
+Workers is the number of requests the Fetcher allows in flight, which is also
+	the number of goroutines worth running against it
+*/
+func (f *Fetcher) Workers() int {
+	return f.config.Workers
+}
+
+/*
+Do makes a request, which must not have a body so that it can be retried.  A
+	worker slot is held from the time the request is sent until its response
+	headers arrive, so the body can be read at whatever pace the caller likes
+	without holding up the requests of other stages.  Waiting for a slot, for
+	the rate limiter or between retries all stop when the context of the
+	request is cancelled.
+*/
+func (f *Fetcher) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		select {
+		case f.workers <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if err := f.limiter.wait(ctx); err != nil {
+			<-f.workers
+			return nil, err
+		}
+
+		resp, err := f.client.Do(req)
+		<-f.workers
+		wait, retry := f.retryAfter(ctx, resp, err, attempt)
+		if retry == false {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/*
+retryAfter decides whether a request should be tried again and, if so, how
+	long to wait first
+*/
+func (f *Fetcher) retryAfter(ctx context.Context, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= f.config.Retries || ctx.Err() != nil {
+		return 0, false
+	}
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return f.backoff(attempt), true
+		}
+		return 0, false
+	}
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return wait, true
+	}
+	return f.backoff(attempt), true
+}
+
+/*
+backoff doubles the base wait for each attempt and then picks a random point
+	in the upper half of that window so that workers don't retry in lockstep
+*/
+func (f *Fetcher) backoff(attempt int) time.Duration {
+	wait := f.config.Backoff << uint(attempt)
+	if wait > f.config.MaxBackoff || wait <= 0 {
+		wait = f.config.MaxBackoff
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+/*
+parseRetryAfter understands both forms of the Retry-After header: a number of
+	seconds or an HTTP date
+*/
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		wait := time.Until(when)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+/*
+tokenBucket is a simple token bucket rate limiter.  A caller that finds the
+	bucket empty takes a token anyway and sleeps until it would have been
+	available, so waiting callers are served in the order they arrived.
+*/
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (tb *tokenBucket) wait(ctx context.Context) error {
+	if tb == nil {
+		return nil
+	}
+	tb.mu.Lock()
+	now := time.Now()
+	tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+	tb.last = now
+	tb.tokens--
+	deficit := -tb.tokens
+	tb.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(deficit / tb.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// The token was never used, so give it back
+		tb.mu.Lock()
+		tb.tokens++
+		tb.mu.Unlock()
+		return ctx.Err()
+	}
+}
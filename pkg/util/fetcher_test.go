@@ -0,0 +1,153 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetcherRetries(t *testing.T) {
+	var fetcherTest = []struct {
+		Name             string
+		FailuresFirst    int32
+		FailureCode      int
+		Retries          int
+		ExpectedCode     int
+		ExpectedAttempts int32
+	}{
+		{"recovers from 503", 2, http.StatusServiceUnavailable, 3, http.StatusOK, 3},
+		{"recovers from 429", 1, http.StatusTooManyRequests, 3, http.StatusOK, 2},
+		{"gives up after retries", 5, http.StatusInternalServerError, 2, http.StatusInternalServerError, 3},
+		{"does not retry 404", 5, http.StatusNotFound, 3, http.StatusNotFound, 1},
+	}
+
+	for _, ex := range fetcherTest {
+		var attempts int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) <= ex.FailuresFirst {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(ex.FailureCode)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+
+		f := NewFetcher(&http.Client{Timeout: 3 * time.Second}, FetcherConfig{Retries: ex.Retries, Workers: 1})
+		resp, err := GetContext(context.Background(), f, ts.URL)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", ex.Name, err)
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode != ex.ExpectedCode {
+				t.Errorf("%s: status codes do not match -> %d vs %d", ex.Name, resp.StatusCode, ex.ExpectedCode)
+			}
+		}
+		if attempts != ex.ExpectedAttempts {
+			t.Errorf("%s: attempts do not match -> %d vs %d", ex.Name, attempts, ex.ExpectedAttempts)
+		}
+		ts.Close()
+	}
+}
+
+func TestFetcherRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(&http.Client{Timeout: 3 * time.Second}, FetcherConfig{Rate: 20, Burst: 1, Workers: 2})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := GetContext(context.Background(), f, ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// The first request uses the burst; the other four wait 50ms apiece
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("Requests were not rate limited: 5 requests took %s", elapsed)
+	}
+}
+
+func TestFetcherCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(&http.Client{Timeout: 3 * time.Second}, FetcherConfig{Retries: 5, Workers: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := GetContext(ctx, f, ts.URL)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the request to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Cancelling did not interrupt the wait between retries: %s", elapsed)
+	}
+}
+
+func TestFetcherOpenBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer ts.Close()
+
+	// A stage that holds a body open while a later stage fetches must not
+	//	deadlock, even with a single worker
+	f := NewFetcher(&http.Client{Timeout: 3 * time.Second}, FetcherConfig{Workers: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	outer, err := GetContext(ctx, f, ts.URL+"/outer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outer.Body.Close()
+	inner, err := GetContext(ctx, f, ts.URL+"/inner")
+	if err != nil {
+		t.Fatalf("Unable to fetch while another body is open: %s", err)
+	}
+	inner.Body.Close()
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	var retryTest = []struct {
+		Value    string
+		Expected time.Duration
+		OK       bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+	}
+
+	for _, ex := range retryTest {
+		wait, ok := parseRetryAfter(ex.Value)
+		if ok != ex.OK || wait != ex.Expected {
+			t.Errorf("Retry-After %q parsed as %s, %t", ex.Value, wait, ok)
+		}
+	}
+}
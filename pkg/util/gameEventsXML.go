@@ -19,6 +19,7 @@ package util
 import (
 	"encoding/xml"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
@@ -108,11 +109,11 @@ type GameEventsXMLGame struct {
 
 /*
 ParseGameEventsXML is a method that opens the locally-saved game_events.xml file and parses the
-	contents into data structures.
+	contents into data structures.  The file may be compressed (see OpenFile).
 */
 func ParseGameEventsXML(path, date string, filePtr *os.File) error {
 	gameID := filepath.Base(filepath.Dir(path))
-	fileReader, err := os.Open(path)
+	fileReader, err := OpenFile(path)
 	if err != nil {
 		return err
 	}
@@ -176,7 +177,7 @@ func printHalfInning(innings GameEventsXMLHalfInning, linePrefix string, filePtr
 			)
 			_, err := filePtr.WriteString(outputString)
 			if err != nil {
-				fmt.Println(err)
+				slog.Error("Unable to write pitch", "err", err)
 			}
 		}
 	}
@@ -201,7 +202,7 @@ func printHalfInning(innings GameEventsXMLHalfInning, linePrefix string, filePtr
 		)
 		_, err := filePtr.WriteString(outputString)
 		if err != nil {
-			fmt.Println(err)
+			slog.Error("Unable to write action", "err", err)
 		}
 	}
 }
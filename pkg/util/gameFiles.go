@@ -0,0 +1,96 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+GameFile is one of the files that gameday publishes in the directory of every game
+*/
+type GameFile struct {
+	// Name is the short name used to select the file on the command line
+	Name string
+	// Path is the location of the file relative to the game directory
+	Path string
+}
+
+/*
+GameFiles is the catalog of per-game gameday files that we know how to use
+*/
+var GameFiles = []GameFile{
+	{Name: "inning_all", Path: "inning/inning_all.xml"},
+	{Name: "game", Path: "game.xml"},
+	{Name: "game_events", Path: "game_events.xml"},
+	{Name: "boxscore", Path: "bis_boxscore.xml"},
+	{Name: "players", Path: "players.xml"},
+	{Name: "linescore", Path: "linescore.xml"},
+	{Name: "inning_hit", Path: "inning/inning_hit.xml"},
+}
+
+/*
+DefaultGameFiles is the selection used when nothing else has been asked for
+*/
+const DefaultGameFiles = "inning_all"
+
+/*
+GameFileHelp describes the values accepted by SelectGameFiles so that every
+	command can use the same flag description
+*/
+func GameFileHelp() string {
+	names := make([]string, len(GameFiles))
+	for i, gameFile := range GameFiles {
+		names[i] = gameFile.Name
+	}
+	return fmt.Sprintf("Comma separated per-game files to download: %s (or all)", strings.Join(names, ","))
+}
+
+/*
+SelectGameFiles turns a comma separated list of names from the catalog into the
+	files they refer to.  The special name "all" selects the whole catalog.
+*/
+func SelectGameFiles(names string) ([]GameFile, error) {
+	var selected []GameFile
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if name == "all" {
+			return GameFiles, nil
+		}
+		found := false
+		for _, gameFile := range GameFiles {
+			if gameFile.Name == name {
+				selected = append(selected, gameFile)
+				found = true
+				break
+			}
+		}
+		if found == false {
+			return nil, fmt.Errorf("unknown gameday file %s", name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no gameday files were selected")
+	}
+	return selected, nil
+}
@@ -19,6 +19,7 @@ package util
 import (
 	"encoding/xml"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
@@ -67,11 +68,11 @@ type GameXMLGame struct {
 
 /*
 ParseGameXML is a method that opens the locally-saved game.xml file and parses the
-	contents into data structures.
+	contents into data structures.  The file may be compressed (see OpenFile).
 */
 func ParseGameXML(path, date string, filePtr *os.File) error {
 	gameID := filepath.Base(filepath.Dir(path))
-	fileReader, err := os.Open(path)
+	fileReader, err := OpenFile(path)
 	if err != nil {
 		return err
 	}
@@ -112,7 +113,7 @@ func ParseGameXML(path, date string, filePtr *os.File) error {
 		)
 		_, err := filePtr.WriteString(teamString)
 		if err != nil {
-			fmt.Println(err)
+			slog.Error("Unable to write team", "err", err)
 		}
 	}
 
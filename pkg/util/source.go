@@ -0,0 +1,63 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+SourceHelp describes the values accepted by NewSourceClient so that every
+	command can use the same flag description
+*/
+const SourceHelp = "Where gameday data comes from: http (the url) or dir:/path (a local mirror of the gameday tree)"
+
+/*
+NewSourceClient builds the HTTP client used to retrieve gameday data.  A source
+	of "http" (or nothing at all) uses the network.  A source of "dir:/path"
+	resolves every request against a local mirror of the gameday directory tree
+	instead, so that a URL such as
+		http://gd2.mlb.com/components/game/mlb/year_2019/month_06/day_10/
+	is read from
+		/path/components/game/mlb/year_2019/month_06/day_10/
+	Directories are answered with a listing of their contents, which is all the
+	gid_ parsers need, and missing files are answered with a 404.
+*/
+func NewSourceClient(source string, timeout time.Duration) (*http.Client, error) {
+	switch {
+	case len(source) == 0, strings.EqualFold(source, "http"):
+		return &http.Client{Timeout: timeout}, nil
+	case strings.HasPrefix(source, "dir:"):
+		root := strings.TrimPrefix(source, "dir:")
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() == false {
+			return nil, fmt.Errorf("the source %s is not a directory", root)
+		}
+		return &http.Client{
+			Timeout:   timeout,
+			Transport: http.NewFileTransport(http.Dir(root)),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown source %s", source)
+}
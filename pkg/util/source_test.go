@@ -0,0 +1,81 @@
+/*
+	Copyright 2019 Brian Bauer
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDirectorySource(t *testing.T) {
+	root, err := ioutil.TempDir("", "gameday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	gamePath := filepath.Join(root, "components/game/mlb/year_2019/month_06/day_10/gid_2019_06_10_nyamlb_bosmlb_1/inning")
+	err = os.MkdirAll(gamePath, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(gamePath, "inning_all.xml"), []byte("<game></game>"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewSourceClient("dir:"+root, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sourceTest = []struct {
+		URL          string
+		ExpectedCode int
+		ExpectedBody string
+	}{
+		{"http://gd2.mlb.com/components/game/mlb/year_2019/month_06/day_10/", http.StatusOK, "href=\"gid_2019_06_10_nyamlb_bosmlb_1/\""},
+		{"http://gd2.mlb.com/components/game/mlb/year_2019/month_06/day_10", http.StatusOK, "href=\"gid_2019_06_10_nyamlb_bosmlb_1/\""},
+		{"http://gd2.mlb.com/components/game/mlb/year_2019/month_06/day_10/gid_2019_06_10_nyamlb_bosmlb_1/inning/inning_all.xml", http.StatusOK, "<game></game>"},
+		{"http://gd2.mlb.com/components/game/mlb/year_2019/month_06/day_11/", http.StatusNotFound, ""},
+	}
+
+	for _, ex := range sourceTest {
+		resp, err := client.Get(ex.URL)
+		if err != nil {
+			t.Errorf("Unable to get %s: %s", ex.URL, err)
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != ex.ExpectedCode {
+			t.Errorf("Status codes do not match for %s -> %d vs %d", ex.URL, resp.StatusCode, ex.ExpectedCode)
+		}
+		if strings.Contains(string(body), ex.ExpectedBody) == false {
+			t.Errorf("Body of %s does not contain %s", ex.URL, ex.ExpectedBody)
+		}
+	}
+
+	if _, err := NewSourceClient("ftp://example.com", time.Second); err == nil {
+		t.Errorf("Expected an error for an unknown source")
+	}
+}
@@ -17,10 +17,10 @@
 package util
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -48,7 +48,8 @@ func DateToURLNoSideEffects(date time.Time, root string) (*url.URL, error) {
 }
 
 /*
-GameToURLsNoSideEffect will turn a game into a set of URLs, given a rootURL
+GameToURLsNoSideEffect will turn a game into a set of URLs, given a rootURL.  There
+	is one URL for every file in the GameFiles catalog.
 */
 func GameToURLsNoSideEffect(game, root string) ([]*url.URL, error) {
 	if len(root) == 0 {
@@ -58,27 +59,14 @@ func GameToURLsNoSideEffect(game, root string) ([]*url.URL, error) {
 	month := game[9:11]
 	day := game[12:14]
 	rawURL := fmt.Sprintf("%s/year_%s/month_%s/day_%s/%s", root, year, month, day, game)
-	gameURLs := make([]*url.URL, 4)
-	newURL, err := url.Parse(rawURL + "game.xml")
-	if err != nil {
-		return nil, err
-	}
-	gameURLs[0] = newURL
-	newURL, err = url.Parse(rawURL + "game_events.xml")
-	if err != nil {
-		return nil, err
+	gameURLs := make([]*url.URL, len(GameFiles))
+	for i, gameFile := range GameFiles {
+		newURL, err := url.Parse(rawURL + gameFile.Path)
+		if err != nil {
+			return nil, err
+		}
+		gameURLs[i] = newURL
 	}
-	gameURLs[1] = newURL
-	newURL, err = url.Parse(rawURL + "inning/inning_all.xml")
-	if err != nil {
-		return nil, err
-	}
-	gameURLs[2] = newURL
-	newURL, err = url.Parse(rawURL + "inning/inning_hit.xml")
-	if err != nil {
-		return nil, err
-	}
-	gameURLs[3] = newURL
 	return gameURLs, nil
 }
 
@@ -137,35 +125,25 @@ func VerifyFSDirectory(fsPath string) error {
 }
 
 /*
-SaveURLToPath downloads a URL to a specific path on the filesystem
+SaveURLToPath downloads a URL to a specific path on the filesystem.  The file
+	is only written for a 2xx response and is never left partially written,
+	and it is compressed when the path ends in .gz or .zst (see SaveResponse).
 */
-func SaveURLToPath(targetURL *url.URL, targetPath string, client *http.Client) error {
+func SaveURLToPath(ctx context.Context, targetURL *url.URL, targetPath string, client Getter) error {
 	// First, make sure the directory exists
 	err := VerifyFSDirectory(targetPath)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Saving %s to %s\n", targetURL, targetPath)
+	slog.Info("Saving file", "url", targetURL.String(), "path", targetPath)
 
 	// Second, make the request
-	res, err := client.Get(targetURL.String())
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-
-	// Third, create the target file
-	filePtr, err := os.Create(targetPath)
-	if err != nil {
-		return err
-	}
-	defer filePtr.Close()
-
-	// Fourth, copy the data into the file
-	_, err = io.Copy(filePtr, res.Body)
+	res, err := GetContext(ctx, client, targetURL.String())
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Third, save the body of the response
+	_, err = SaveResponse(res, targetPath)
+	return err
 }
@@ -73,3 +73,34 @@ func TestURLToFSPath(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectGameFiles(t *testing.T) {
+	var selectTest = []struct {
+		Names         string
+		ExpectedPaths []string
+		ExpectError   bool
+	}{
+		{"inning_all", []string{"inning/inning_all.xml"}, false},
+		{"Boxscore, game,boxscore", []string{"bis_boxscore.xml", "game.xml"}, false},
+		{"all", []string{"inning/inning_all.xml", "game.xml", "game_events.xml", "bis_boxscore.xml", "players.xml", "linescore.xml", "inning/inning_hit.xml"}, false},
+		{"inning_all,box", nil, true},
+		{"", nil, true},
+	}
+
+	for _, ex := range selectTest {
+		files, err := SelectGameFiles(ex.Names)
+		if (err != nil) != ex.ExpectError {
+			t.Errorf("Unexpected result for %s -> %v", ex.Names, err)
+			continue
+		}
+		if len(files) != len(ex.ExpectedPaths) {
+			t.Errorf("Wrong number of files for %s -> %d vs %d", ex.Names, len(files), len(ex.ExpectedPaths))
+			continue
+		}
+		for i, file := range files {
+			if file.Path != ex.ExpectedPaths[i] {
+				t.Errorf("Paths do not match -> %s vs %s", file.Path, ex.ExpectedPaths[i])
+			}
+		}
+	}
+}